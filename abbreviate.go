@@ -0,0 +1,177 @@
+package ntto
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Abbreviator replaces rule prefixes in N-Triples lines natively, without
+// perl or replace. Unlike the external commands, it only touches IRIs in angle
+// brackets, never literals, and always applies the rule with the longest
+// matching prefix. It counts replacements per rule as it goes; it is not safe
+// for concurrent use.
+type Abbreviator struct {
+	index    *prefixIndex
+	null     string
+	rules    []Rule
+	hits     map[string]int64
+	saved    map[string]int64
+	inBytes  int64
+	outBytes int64
+}
+
+// NewAbbreviator returns an abbreviator for the rules. Rules with null as
+// shortcut remove their prefix altogether.
+func NewAbbreviator(rules []Rule, null string) *Abbreviator {
+	return &Abbreviator{
+		index: newPrefixIndex(rules),
+		null:  null,
+		rules: rules,
+		hits:  make(map[string]int64),
+		saved: make(map[string]int64),
+	}
+}
+
+// Abbreviate returns the line with all IRIs abbreviated.
+func (a *Abbreviator) Abbreviate(line string) string {
+	out := mapIRIs(line, a.abbreviateIRI)
+	a.inBytes += int64(len(line) + 1)
+	a.outBytes += int64(len(out) + 1)
+	return out
+}
+
+// mapIRIs replaces the content of every IRI in angle brackets in an
+// N-Triples line with f applied to it. Literals are copied as they are.
+func mapIRIs(line string, f func(string) string) string {
+	var sb strings.Builder
+	sb.Grow(len(line))
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '"':
+			// copy the literal up to its closing quote
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(line) {
+				j = len(line) - 1
+			}
+			sb.WriteString(line[i : j+1])
+			i = j
+		case '<':
+			j := strings.IndexByte(line[i:], '>')
+			if j < 0 {
+				sb.WriteString(line[i:])
+				i = len(line)
+				continue
+			}
+			sb.WriteByte('<')
+			sb.WriteString(f(line[i+1 : i+j]))
+			sb.WriteByte('>')
+			i += j
+		default:
+			sb.WriteByte(line[i])
+		}
+	}
+	return sb.String()
+}
+
+func (a *Abbreviator) abbreviateIRI(iri string) string {
+	rule, ok := a.index.match(iri)
+	if !ok {
+		return iri
+	}
+	var short string
+	if rule.Shortcut == a.null {
+		short = iri[len(rule.Prefix):]
+	} else {
+		short = rule.Shortcut + ":" + iri[len(rule.Prefix):]
+	}
+	a.hits[rule.Prefix]++
+	a.saved[rule.Prefix] += int64(len(iri) - len(short))
+	return short
+}
+
+// Expander turns abbreviated IRIs like <gnd:118540238> back into full IRIs.
+// Rules with a null shortcut cannot be reversed and are ignored.
+type Expander struct {
+	index *prefixIndex
+}
+
+// NewExpander returns an expander for the rules. If several rules share a
+// shortcut, the first one wins, as when abbreviating.
+func NewExpander(rules []Rule, null string) *Expander {
+	var reversible []Rule
+	for _, rule := range rules {
+		if rule.Shortcut != null {
+			reversible = append(reversible, rule)
+		}
+	}
+	return &Expander{index: newPrefixIndex(reversible)}
+}
+
+// Expand returns the line with all abbreviated IRIs expanded. Literals are
+// left alone.
+func (e *Expander) Expand(line string) string {
+	return mapIRIs(line, e.index.expand)
+}
+
+// AbbreviationReport tells how much each rule saved.
+type AbbreviationReport struct {
+	InputBytes  int64        `json:"input_bytes"`
+	OutputBytes int64        `json:"output_bytes"`
+	Rules       []RuleReport `json:"rules"`
+}
+
+// Report returns the counts so far, one entry per rule, in rule order. Rules
+// shadowed by an earlier rule with the same prefix never match.
+func (a *Abbreviator) Report() AbbreviationReport {
+	r := AbbreviationReport{InputBytes: a.inBytes, OutputBytes: a.outBytes}
+	seen := make(map[string]bool)
+	for _, rule := range a.rules {
+		rr := RuleReport{Shortcut: rule.Shortcut, Prefix: rule.Prefix}
+		if !seen[rule.Prefix] {
+			rr.Hits, rr.Saved = a.hits[rule.Prefix], a.saved[rule.Prefix]
+		}
+		seen[rule.Prefix] = true
+		r.Rules = append(r.Rules, rr)
+	}
+	return r
+}
+
+// Unused returns the rules that never matched.
+func (r AbbreviationReport) Unused() []RuleReport {
+	var unused []RuleReport
+	for _, rr := range r.Rules {
+		if rr.Hits == 0 {
+			unused = append(unused, rr)
+		}
+	}
+	return unused
+}
+
+// WriteTable writes the report as a plain text table, flagging rules that
+// never matched.
+func (r AbbreviationReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "rule\tprefix\thits\tsaved\t\n")
+	for _, rr := range r.Rules {
+		flag := ""
+		if rr.Hits == 0 {
+			flag = "never matched"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", rr.Shortcut, rr.Prefix, rr.Hits, rr.Saved, flag)
+	}
+	fmt.Fprintf(tw, "\ninput\t%d bytes\n", r.InputBytes)
+	fmt.Fprintf(tw, "output\t%d bytes\n", r.OutputBytes)
+	if r.InputBytes > 0 {
+		fmt.Fprintf(tw, "saved\t%d bytes (%.1f%%)\n", r.InputBytes-r.OutputBytes,
+			100*float64(r.InputBytes-r.OutputBytes)/float64(r.InputBytes))
+	}
+	fmt.Fprintf(tw, "unused rules\t%d of %d\n", len(r.Unused()), len(r.Rules))
+	return tw.Flush()
+}
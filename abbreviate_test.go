@@ -0,0 +1,89 @@
+package ntto
+
+import (
+	"reflect"
+	"testing"
+)
+
+var abbreviateRules = []Rule{
+	Rule{Shortcut: "dnbvo", Prefix: "http://d-nb.info/standards/vocab/gnd/"},
+	Rule{Shortcut: "dnbac", Prefix: "http://d-nb.info/standards/vocab/gnd/geographic-area-code#"},
+	Rule{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/"},
+	Rule{Shortcut: "<NULL>", Prefix: "http://example.org/"},
+	Rule{Shortcut: "xsd", Prefix: "http://www.w3.org/2001/XMLSchema#"},
+	Rule{Shortcut: "foaf", Prefix: "http://xmlns.com/foaf/0.1/"},
+}
+
+var AbbreviatorTests = []struct {
+	in  string
+	out string
+}{
+	{`<http://d-nb.info/gnd/1> <http://d-nb.info/standards/vocab/gnd/geographic-area-code#XA-DE> <http://d-nb.info/standards/vocab/gnd/x> .`,
+		`<gnd:1> <dnbac:XA-DE> <dnbvo:x> .`},
+	{`<http://example.org/a> <http://d-nb.info/gnd/p> "see <http://d-nb.info/gnd/1> and \"http://d-nb.info/gnd/\"" .`,
+		`<a> <gnd:p> "see <http://d-nb.info/gnd/1> and \"http://d-nb.info/gnd/\"" .`},
+	{`_:b0 <http://d-nb.info/gnd/p> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`_:b0 <gnd:p> "1"^^<xsd:integer> .`},
+	{`<http://other.org/a> <http://other.org/b> <http://other.org/c> .`,
+		`<http://other.org/a> <http://other.org/b> <http://other.org/c> .`},
+	{`<broken`, `<broken`},
+	{`<a> <b> "unterminated`, `<a> <b> "unterminated`},
+}
+
+func TestAbbreviator(t *testing.T) {
+	for _, tt := range AbbreviatorTests {
+		a := NewAbbreviator(abbreviateRules, "<NULL>")
+		if out := a.Abbreviate(tt.in); out != tt.out {
+			t.Errorf("Abbreviate(%s) => %s, want: %s", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestAbbreviatorReport(t *testing.T) {
+	a := NewAbbreviator(abbreviateRules, "<NULL>")
+	for _, tt := range AbbreviatorTests {
+		a.Abbreviate(tt.in)
+	}
+	r := a.Report()
+	var in, out int64
+	for _, tt := range AbbreviatorTests {
+		in += int64(len(tt.in) + 1)
+		out += int64(len(tt.out) + 1)
+	}
+	if r.InputBytes != in || r.OutputBytes != out {
+		t.Errorf("Report() => %d/%d bytes, want: %d/%d", r.InputBytes, r.OutputBytes, in, out)
+	}
+	want := []RuleReport{
+		{Shortcut: "dnbvo", Prefix: "http://d-nb.info/standards/vocab/gnd/", Hits: 1, Saved: 31},
+		{Shortcut: "dnbac", Prefix: "http://d-nb.info/standards/vocab/gnd/geographic-area-code#", Hits: 1, Saved: 52},
+		{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/", Hits: 3, Saved: 51},
+		{Shortcut: "<NULL>", Prefix: "http://example.org/", Hits: 1, Saved: 19},
+		{Shortcut: "xsd", Prefix: "http://www.w3.org/2001/XMLSchema#", Hits: 1, Saved: 29},
+		{Shortcut: "foaf", Prefix: "http://xmlns.com/foaf/0.1/"},
+	}
+	if !reflect.DeepEqual(r.Rules, want) {
+		t.Errorf("Report().Rules => %+v, want: %+v", r.Rules, want)
+	}
+	if unused := r.Unused(); len(unused) != 1 || unused[0].Shortcut != "foaf" {
+		t.Errorf("Report().Unused() => %+v, want: foaf", unused)
+	}
+}
+
+func TestExpander(t *testing.T) {
+	e := NewExpander(abbreviateRules, "<NULL>")
+	// everything but the null rule round trips
+	for _, tt := range AbbreviatorTests[:1] {
+		if out := e.Expand(tt.out); out != tt.in {
+			t.Errorf("Expand(%s) => %s, want: %s", tt.out, out, tt.in)
+		}
+	}
+	for _, tt := range AbbreviatorTests[2:] {
+		if out := e.Expand(tt.out); out != tt.in {
+			t.Errorf("Expand(%s) => %s, want: %s", tt.out, out, tt.in)
+		}
+	}
+	in := `<a> <gnd:p> "<gnd:1>" .`
+	if out, want := e.Expand(in), `<a> <http://d-nb.info/gnd/p> "<gnd:1>" .`; out != want {
+		t.Errorf("Expand(%s) => %s, want: %s", in, out, want)
+	}
+}
@@ -0,0 +1,92 @@
+package ntto
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// AppendJSON appends the JSON object of a triple to dst, byte for byte what
+// json.Marshal returns, without reflection and without allocating, if dst
+// has room.
+func (t *Triple) AppendJSON(dst []byte) []byte {
+	dst = append(dst, `{"s":`...)
+	dst = appendJSONString(dst, t.Subject)
+	dst = append(dst, `,"p":`...)
+	dst = appendJSONString(dst, t.Predicate)
+	dst = append(dst, `,"o":`...)
+	dst = appendJSONString(dst, t.Object)
+	return append(dst, '}')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// The escapes encoding/json uses for \b, \f and invalid UTF-8 changed
+// between Go versions, so they are taken from encoding/json itself.
+var (
+	escapeBackspace = jsonEscape("\b")
+	escapeFormFeed  = jsonEscape("\f")
+	escapeInvalid   = jsonEscape("\xff")
+)
+
+// jsonEscape returns s as escaped by encoding/json, without quotes.
+func jsonEscape(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	return string(b[1 : len(b)-1])
+}
+
+// appendJSONString appends s as a JSON string, escaped like encoding/json
+// does with HTML escaping: invalid UTF-8 is replaced by U+FFFD, and <, >, &,
+// U+2028 and U+2029 are escaped.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			case '\b':
+				dst = append(dst, escapeBackspace...)
+			case '\f':
+				dst = append(dst, escapeFormFeed...)
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, escapeInvalid...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,89 @@
+package ntto
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+var appendJSONSeeds = []string{
+	"",
+	"http://example.org/a",
+	`say "hi" \ bye`,
+	"<b> & </b>",
+	"tab\tnewline\nreturn\r\b\f\x00\x1f\x7f",
+	"äöü 日本語 😀",
+	"\xff\xfe broken \xc3",
+	"line\u2028para\u2029",
+	`\u00e4 is no escape`,
+}
+
+func TestAppendJSON(t *testing.T) {
+	for _, s := range appendJSONSeeds {
+		triple := &Triple{Subject: s, Predicate: "p", Object: s}
+		want, err := json.Marshal(triple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := triple.AppendJSON(nil); string(got) != string(want) {
+			t.Errorf("AppendJSON(%q): got %s, want %s", s, got, want)
+		}
+	}
+}
+
+func FuzzAppendJSON(f *testing.F) {
+	for _, s := range appendJSONSeeds {
+		f.Add(s, "p", s)
+	}
+	f.Fuzz(func(t *testing.T, s, p, o string) {
+		triple := &Triple{Subject: s, Predicate: p, Object: o}
+		want, err := json.Marshal(triple)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := triple.AppendJSON(nil); string(got) != string(want) {
+			t.Errorf("AppendJSON(%q, %q, %q): got %s, want %s", s, p, o, got, want)
+		}
+	})
+}
+
+func TestAppendJSONAllocs(t *testing.T) {
+	triple := &Triple{Subject: "http://example.org/a", Predicate: "http://example.org/p", Object: "<A> & \"B\""}
+	buf := make([]byte, 0, 256)
+	if n := testing.AllocsPerRun(100, func() { buf = triple.AppendJSON(buf[:0]) }); n != 0 {
+		t.Errorf("got %v allocations, want 0", n)
+	}
+}
+
+var benchmarkTriple = &Triple{
+	Subject:    "http://d-nb.info/gnd/118540238",
+	Predicate:  "http://d-nb.info/standards/elementset/gnd#preferredNameForThePerson",
+	Object:     "Goethe, Johann Wolfgang von",
+	ObjectKind: Literal,
+}
+
+// BenchmarkJSONMarshal is the encoding before AppendJSON: json.Marshal and
+// two writes per triple.
+func BenchmarkJSONMarshal(b *testing.B) {
+	w := bufio.NewWriter(ioutil.Discard)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out, err := json.Marshal(benchmarkTriple)
+		if err != nil {
+			b.Fatal(err)
+		}
+		w.Write(out)
+		w.Write([]byte("\n"))
+	}
+}
+
+func BenchmarkJSONEncoder(b *testing.B) {
+	e := NewJSONEncoder(bufio.NewWriter(ioutil.Discard))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := e.Encode(benchmarkTriple); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
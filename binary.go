@@ -0,0 +1,474 @@
+package ntto
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Binary records are written by MessagePack and CBOR encoders, one per triple
+// or quad, each prefixed by its length as a 4 byte big endian integer, so
+// readers can skip records or split streams without decoding them. A record
+// is a map with string keys:
+//
+//	s     subject
+//	p     predicate
+//	o     object, literals with their N-Triples escapes
+//	k     object kind, 1 for literals, 2 for blank nodes, left out for IRIs
+//	lang  language tag, if any
+//	dt    datatype, if any
+//	g     graph, if any
+//
+// Readers skip keys they do not know, if their values are strings, integers,
+// booleans or nil.
+const (
+	recordSubject   = "s"
+	recordPredicate = "p"
+	recordObject    = "o"
+	recordKind      = "k"
+	recordLang      = "lang"
+	recordDatatype  = "dt"
+	recordGraph     = "g"
+)
+
+// maxRecordSize limits the size of a record a reader accepts.
+const maxRecordSize = 64 << 20
+
+var errBrokenRecord = errors.New("broken record")
+
+// binaryFormat is the wire format of the values in a record.
+type binaryFormat interface {
+	appendMap(dst []byte, n int) []byte
+	appendString(dst []byte, s string) []byte
+	appendUint(dst []byte, n uint64) []byte
+	// readMap, readString and readUint return the value at the start of b
+	// and the number of bytes read.
+	readMap(b []byte) (int, int, error)
+	readString(b []byte) (string, int, error)
+	readUint(b []byte) (uint64, int, error)
+	// skip returns the size of the scalar value at the start of b.
+	skip(b []byte) (int, error)
+}
+
+// appendRecord appends the length prefixed record of a quad.
+func appendRecord(dst []byte, f binaryFormat, q *Quad) []byte {
+	n := 3
+	if q.ObjectKind != IRI {
+		n++
+	}
+	if q.Lang != "" {
+		n++
+	}
+	if q.Datatype != "" {
+		n++
+	}
+	if q.Graph != "" {
+		n++
+	}
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	dst = f.appendMap(dst, n)
+	dst = f.appendString(f.appendString(dst, recordSubject), q.Subject)
+	dst = f.appendString(f.appendString(dst, recordPredicate), q.Predicate)
+	dst = f.appendString(f.appendString(dst, recordObject), q.Object)
+	if q.ObjectKind != IRI {
+		dst = f.appendUint(f.appendString(dst, recordKind), uint64(q.ObjectKind))
+	}
+	if q.Lang != "" {
+		dst = f.appendString(f.appendString(dst, recordLang), q.Lang)
+	}
+	if q.Datatype != "" {
+		dst = f.appendString(f.appendString(dst, recordDatatype), q.Datatype)
+	}
+	if q.Graph != "" {
+		dst = f.appendString(f.appendString(dst, recordGraph), q.Graph)
+	}
+	binary.BigEndian.PutUint32(dst[start:], uint32(len(dst)-start-4))
+	return dst
+}
+
+// parseRecord decodes a record without its length prefix.
+func parseRecord(b []byte, f binaryFormat) (*Quad, error) {
+	n, i, err := f.readMap(b)
+	if err != nil {
+		return nil, err
+	}
+	q := &Quad{}
+	for ; n > 0; n-- {
+		key, size, err := f.readString(b[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += size
+		var value string
+		switch key {
+		case recordSubject, recordPredicate, recordObject, recordLang, recordDatatype, recordGraph:
+			value, size, err = f.readString(b[i:])
+		case recordKind:
+			var kind uint64
+			kind, size, err = f.readUint(b[i:])
+			if err == nil && kind > uint64(BlankNode) {
+				err = fmt.Errorf("unknown object kind: %d", kind)
+			}
+			q.ObjectKind = Kind(kind)
+		default:
+			size, err = f.skip(b[i:])
+		}
+		if err != nil {
+			return nil, err
+		}
+		i += size
+		switch key {
+		case recordSubject:
+			q.Subject = value
+		case recordPredicate:
+			q.Predicate = value
+		case recordObject:
+			q.Object = value
+		case recordLang:
+			q.Lang = value
+		case recordDatatype:
+			q.Datatype = value
+		case recordGraph:
+			q.Graph = value
+		}
+	}
+	if i != len(b) {
+		return nil, errBrokenRecord
+	}
+	return q, nil
+}
+
+// BinaryEncoder writes length prefixed MessagePack or CBOR records.
+type BinaryEncoder struct {
+	w      io.Writer
+	format binaryFormat
+	buf    []byte
+}
+
+// NewMessagePackEncoder returns an encoder writing MessagePack records to w.
+func NewMessagePackEncoder(w io.Writer) *BinaryEncoder {
+	return &BinaryEncoder{w: w, format: msgpackFormat{}}
+}
+
+// NewCBOREncoder returns an encoder writing CBOR records to w.
+func NewCBOREncoder(w io.Writer) *BinaryEncoder {
+	return &BinaryEncoder{w: w, format: cborFormat{}}
+}
+
+// Encode writes a single triple.
+func (e *BinaryEncoder) Encode(t *Triple) error {
+	return e.EncodeQuad(&Quad{Triple: *t})
+}
+
+// EncodeQuad writes a single quad.
+func (e *BinaryEncoder) EncodeQuad(q *Quad) error {
+	e.buf = appendRecord(e.buf[:0], e.format, q)
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// Flush is a no-op, BinaryEncoder does not buffer.
+func (e *BinaryEncoder) Flush() error {
+	return nil
+}
+
+// BinaryReader reads the records written by a BinaryEncoder.
+type BinaryReader struct {
+	r      *bufio.Reader
+	format binaryFormat
+	buf    []byte
+}
+
+// NewMessagePackReader returns a reader for MessagePack records.
+func NewMessagePackReader(r io.Reader) *BinaryReader {
+	return &BinaryReader{r: bufio.NewReader(r), format: msgpackFormat{}}
+}
+
+// NewCBORReader returns a reader for CBOR records.
+func NewCBORReader(r io.Reader) *BinaryReader {
+	return &BinaryReader{r: bufio.NewReader(r), format: cborFormat{}}
+}
+
+// Next returns the next triple, or io.EOF. Graphs are dropped.
+func (r *BinaryReader) Next() (*Triple, error) {
+	q, err := r.NextQuad()
+	if err != nil {
+		return nil, err
+	}
+	return &q.Triple, nil
+}
+
+// NextQuad returns the next quad, or io.EOF.
+func (r *BinaryReader) NextQuad() (*Quad, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r.r, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errBrokenRecord
+		}
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(prefix[:])
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("record too large: %d bytes", size)
+	}
+	if cap(r.buf) < int(size) {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+	if _, err := io.ReadFull(r.r, r.buf); err != nil {
+		return nil, errBrokenRecord
+	}
+	return parseRecord(r.buf, r.format)
+}
+
+// appendUint64 appends n as 8 bytes, big endian.
+func appendUint64(dst []byte, n uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	return append(dst, b[:]...)
+}
+
+// msgpackFormat is MessagePack, https://msgpack.org.
+type msgpackFormat struct{}
+
+func (msgpackFormat) appendMap(dst []byte, n int) []byte {
+	if n < 16 {
+		return append(dst, 0x80|byte(n))
+	}
+	return append(dst, 0xde, byte(n>>8), byte(n))
+}
+
+func (msgpackFormat) appendString(dst []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		dst = append(dst, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		dst = append(dst, 0xda, byte(n>>8), byte(n))
+	default:
+		dst = append(dst, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(dst, s...)
+}
+
+func (msgpackFormat) appendUint(dst []byte, n uint64) []byte {
+	switch {
+	case n < 128:
+		return append(dst, byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, 0xcd, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(dst, 0xce, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return appendUint64(append(dst, 0xcf), n)
+}
+
+// msgpackLength reads a big endian length of size bytes after the type byte.
+func msgpackLength(b []byte, size int) (int, int, error) {
+	if len(b) < 1+size {
+		return 0, 0, errBrokenRecord
+	}
+	var n uint64
+	for _, c := range b[1 : 1+size] {
+		n = n<<8 | uint64(c)
+	}
+	if n > maxRecordSize {
+		return 0, 0, errBrokenRecord
+	}
+	return int(n), 1 + size, nil
+}
+
+func (msgpackFormat) readMap(b []byte) (int, int, error) {
+	if len(b) == 0 {
+		return 0, 0, errBrokenRecord
+	}
+	switch c := b[0]; {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f), 1, nil
+	case c == 0xde:
+		return msgpackLength(b, 2)
+	case c == 0xdf:
+		return msgpackLength(b, 4)
+	}
+	return 0, 0, errBrokenRecord
+}
+
+func (msgpackFormat) readString(b []byte) (string, int, error) {
+	if len(b) == 0 {
+		return "", 0, errBrokenRecord
+	}
+	var n, i int
+	var err error
+	switch c := b[0]; {
+	case c&0xe0 == 0xa0:
+		n, i = int(c&0x1f), 1
+	case c == 0xd9 || c == 0xc4:
+		n, i, err = msgpackLength(b, 1)
+	case c == 0xda || c == 0xc5:
+		n, i, err = msgpackLength(b, 2)
+	case c == 0xdb || c == 0xc6:
+		n, i, err = msgpackLength(b, 4)
+	default:
+		return "", 0, errBrokenRecord
+	}
+	if err != nil || len(b) < i+n {
+		return "", 0, errBrokenRecord
+	}
+	return string(b[i : i+n]), i + n, nil
+}
+
+func (msgpackFormat) readUint(b []byte) (uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, errBrokenRecord
+	}
+	size := 0
+	switch c := b[0]; {
+	case c < 0x80:
+		return uint64(c), 1, nil
+	case c == 0xcc:
+		size = 1
+	case c == 0xcd:
+		size = 2
+	case c == 0xce:
+		size = 4
+	case c == 0xcf:
+		size = 8
+	default:
+		return 0, 0, errBrokenRecord
+	}
+	if len(b) < 1+size {
+		return 0, 0, errBrokenRecord
+	}
+	var n uint64
+	for _, c := range b[1 : 1+size] {
+		n = n<<8 | uint64(c)
+	}
+	return n, 1 + size, nil
+}
+
+func (f msgpackFormat) skip(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, errBrokenRecord
+	}
+	switch c := b[0]; {
+	case c < 0x80 || c >= 0xe0 || c == 0xc0 || c == 0xc2 || c == 0xc3:
+		// fixint, negative fixint, nil, false, true
+		return 1, nil
+	case c >= 0xcc && c <= 0xd3:
+		// unsigned and signed integers of 1, 2, 4 and 8 bytes
+		size := 1 + 1<<((c-0xcc)%4)
+		if len(b) < size {
+			return 0, errBrokenRecord
+		}
+		return size, nil
+	}
+	_, size, err := f.readString(b)
+	return size, err
+}
+
+// cborFormat is CBOR, RFC 8949.
+type cborFormat struct{}
+
+// appendHead appends the initial bytes of a data item of a major type with
+// an argument.
+func (cborFormat) appendHead(dst []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(dst, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(dst, major|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return appendUint64(append(dst, major|27), n)
+}
+
+// readHead returns major type and argument of the data item at the start of
+// b, and the size of its head.
+func (cborFormat) readHead(b []byte) (byte, uint64, int, error) {
+	if len(b) == 0 {
+		return 0, 0, 0, errBrokenRecord
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	if info < 24 {
+		return major, uint64(info), 1, nil
+	}
+	if info > 27 {
+		// indefinite lengths and reserved values
+		return 0, 0, 0, errBrokenRecord
+	}
+	size := 1 << (info - 24)
+	if len(b) < 1+size {
+		return 0, 0, 0, errBrokenRecord
+	}
+	var n uint64
+	for _, c := range b[1 : 1+size] {
+		n = n<<8 | uint64(c)
+	}
+	return major, n, 1 + size, nil
+}
+
+func (f cborFormat) appendMap(dst []byte, n int) []byte {
+	return f.appendHead(dst, 5, uint64(n))
+}
+
+func (f cborFormat) appendString(dst []byte, s string) []byte {
+	return append(f.appendHead(dst, 3, uint64(len(s))), s...)
+}
+
+func (f cborFormat) appendUint(dst []byte, n uint64) []byte {
+	return f.appendHead(dst, 0, n)
+}
+
+func (f cborFormat) readMap(b []byte) (int, int, error) {
+	major, n, i, err := f.readHead(b)
+	if err != nil || major != 5 || n > maxRecordSize {
+		return 0, 0, errBrokenRecord
+	}
+	return int(n), i, nil
+}
+
+func (f cborFormat) readString(b []byte) (string, int, error) {
+	major, n, i, err := f.readHead(b)
+	if err != nil || (major != 2 && major != 3) || n > uint64(len(b)-i) {
+		return "", 0, errBrokenRecord
+	}
+	return string(b[i : i+int(n)]), i + int(n), nil
+}
+
+func (f cborFormat) readUint(b []byte) (uint64, int, error) {
+	major, n, i, err := f.readHead(b)
+	if err != nil || major != 0 {
+		return 0, 0, errBrokenRecord
+	}
+	return n, i, nil
+}
+
+func (f cborFormat) skip(b []byte) (int, error) {
+	major, n, i, err := f.readHead(b)
+	switch {
+	case err != nil:
+		return 0, err
+	case major == 0 || major == 1:
+		return i, nil
+	case major == 2 || major == 3:
+		if n > uint64(len(b)-i) {
+			return 0, errBrokenRecord
+		}
+		return i + int(n), nil
+	case major == 7 && n >= 20 && n <= 22 && i == 1:
+		// false, true, null
+		return 1, nil
+	}
+	return 0, errBrokenRecord
+}
@@ -0,0 +1,127 @@
+package ntto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+)
+
+var binaryQuads = []Quad{
+	{Triple: Triple{Subject: "a", Predicate: "p", Object: "b"}},
+	{Triple: Triple{Subject: "_:b0", Predicate: "p", Object: "x", ObjectKind: Literal, Lang: "en"}, Graph: "g"},
+	{Triple: Triple{Subject: "a", Predicate: "p", Object: "_:b1", ObjectKind: BlankNode}},
+	{Triple: Triple{Subject: "a", Predicate: "p", Object: "1", ObjectKind: Literal,
+		Datatype: "http://www.w3.org/2001/XMLSchema#integer"}},
+	{Triple: Triple{Subject: strings.Repeat("s", 40), Predicate: strings.Repeat("p", 300),
+		Object: strings.Repeat("ö", 40000), ObjectKind: Literal}},
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	formats := []struct {
+		name      string
+		newEncode func(io.Writer) *BinaryEncoder
+		newReader func(io.Reader) *BinaryReader
+	}{
+		{"msgpack", NewMessagePackEncoder, NewMessagePackReader},
+		{"cbor", NewCBOREncoder, NewCBORReader},
+	}
+	for _, f := range formats {
+		var buf bytes.Buffer
+		e := f.newEncode(&buf)
+		for i := range binaryQuads {
+			if err := e.EncodeQuad(&binaryQuads[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		r := f.newReader(&buf)
+		for i, want := range binaryQuads {
+			q, err := r.NextQuad()
+			if err != nil {
+				t.Fatalf("%s: record %d: %v", f.name, i, err)
+			}
+			if *q != want {
+				t.Errorf("%s: got %v, want %v", f.name, q, want)
+			}
+		}
+		if _, err := r.Next(); err != io.EOF {
+			t.Errorf("%s: got %v, want EOF", f.name, err)
+		}
+	}
+}
+
+var BinaryRecordTests = []struct {
+	name   string
+	format binaryFormat
+	out    string
+}{
+	// {"s": "a", "p": "p", "o": "x", "k": 1, "lang": "en"}
+	{"msgpack", msgpackFormat{}, "00000018" + "85" + "a173a161" + "a170a170" + "a16fa178" + "a16b01" + "a46c616e67a2656e"},
+	{"cbor", cborFormat{}, "00000018" + "a5" + "61736161" + "61706170" + "616f6178" + "616b01" + "646c616e6762656e"},
+}
+
+func TestBinaryRecord(t *testing.T) {
+	q := &Quad{Triple: Triple{Subject: "a", Predicate: "p", Object: "x", ObjectKind: Literal, Lang: "en"}}
+	for _, tt := range BinaryRecordTests {
+		if got := hex.EncodeToString(appendRecord(nil, tt.format, q)); got != tt.out {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.out)
+		}
+	}
+}
+
+var ParseRecordTests = []struct {
+	name   string
+	format binaryFormat
+	record string
+	err    bool
+}{
+	// unknown keys with nil, bool and integer values are skipped
+	{"msgpack", msgpackFormat{}, "85" + "a173a161" + "a170a170" + "a16fa162" + "a178c0" + "a179cd0102", false},
+	{"cbor", cborFormat{}, "a5" + "61736161" + "61706170" + "616f6162" + "6178f5" + "6179190102", false},
+	{"msgpack", msgpackFormat{}, "83" + "a173a161" + "a170a170" + "a16f", true},
+	{"msgpack", msgpackFormat{}, "81" + "a16b05", true},
+	{"msgpack", msgpackFormat{}, "81" + "a17890", true},
+	{"cbor", cborFormat{}, "bf" + "61736161" + "ff", true},
+	{"cbor", cborFormat{}, "a1" + "6173" + "00", true},
+	{"cbor", cborFormat{}, "a0" + "00", true},
+}
+
+func TestParseRecord(t *testing.T) {
+	for _, tt := range ParseRecordTests {
+		b, err := hex.DecodeString(tt.record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q, err := parseRecord(b, tt.format)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s %s: expected error", tt.name, tt.record)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %s: %v", tt.name, tt.record, err)
+			continue
+		}
+		if q.Subject != "a" || q.Predicate != "p" || q.Object != "b" {
+			t.Errorf("%s %s: got %v", tt.name, tt.record, q)
+		}
+	}
+}
+
+func TestBinaryReaderTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewCBOREncoder(&buf).Encode(&binaryQuads[0].Triple); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	for _, n := range []int{2, len(b) - 1} {
+		if _, err := NewCBORReader(bytes.NewReader(b[:n])).Next(); err == nil || err == io.EOF {
+			t.Errorf("%d bytes: got %v, want error", n, err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package ntto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BlankNodeHasher derives labels for blank nodes from the triples they occur
+// in, so the same structure gets the same labels in different files. Each
+// blank node is identified by the triples around it, with itself and other
+// blank nodes masked. Blank nodes with identical surroundings cannot be told
+// apart and keep their original labels, prefixed with t, so they never clash
+// with the hashed labels, which start with h.
+type BlankNodeHasher struct {
+	sums map[string]uint64
+}
+
+// NewBlankNodeHasher returns an empty hasher.
+func NewBlankNodeHasher() *BlankNodeHasher {
+	return &BlankNodeHasher{sums: make(map[string]uint64)}
+}
+
+// Add records a quad. Each quad must be added only once, since the labels
+// depend on how often a blank node occurs.
+func (h *BlankNodeHasher) Add(q *Quad) {
+	for _, b := range q.blankNodes() {
+		masked := q.mapBlankNodes(func(label string) string {
+			if label == b {
+				return "_:self"
+			}
+			return "_:other"
+		})
+		h.sums[b] += hash64(masked.String())
+	}
+}
+
+// Labels returns the new label for each blank node seen.
+func (h *BlankNodeHasher) Labels() map[string]string {
+	count := make(map[uint64]int)
+	for _, sum := range h.sums {
+		count[sum]++
+	}
+	labels := make(map[string]string, len(h.sums))
+	for b, sum := range h.sums {
+		if count[sum] > 1 {
+			labels[b] = "_:t" + b[2:]
+			continue
+		}
+		labels[b] = fmt.Sprintf("_:h%016x", sum)
+	}
+	return labels
+}
+
+// blankNodes returns the distinct blank nodes of a quad.
+func (q *Quad) blankNodes() []string {
+	var result []string
+	add := func(s string) {
+		if !strings.HasPrefix(s, "_:") {
+			return
+		}
+		for _, b := range result {
+			if b == s {
+				return
+			}
+		}
+		result = append(result, s)
+	}
+	add(q.Subject)
+	if q.ObjectKind == BlankNode {
+		add(q.Object)
+	}
+	add(q.Graph)
+	return result
+}
+
+// mapBlankNodes returns a copy of the quad with every blank node replaced.
+func (q *Quad) mapBlankNodes(f func(string) string) *Quad {
+	c := *q
+	if strings.HasPrefix(c.Subject, "_:") {
+		c.Subject = f(c.Subject)
+	}
+	if c.ObjectKind == BlankNode {
+		c.Object = f(c.Object)
+	}
+	if strings.HasPrefix(c.Graph, "_:") {
+		c.Graph = f(c.Graph)
+	}
+	return &c
+}
+
+// RelabelBlankNodes returns the quad with blank nodes renamed according to
+// labels. Blank nodes without a label stay as they are.
+func RelabelBlankNodes(q *Quad, labels map[string]string) *Quad {
+	return q.mapBlankNodes(func(b string) string {
+		if label, ok := labels[b]; ok {
+			return label
+		}
+		return b
+	})
+}
+
+// PrefixBlankNodes returns the quad with prefix put in front of every blank
+// node label, so labels from different files do not collide.
+func PrefixBlankNodes(q *Quad, prefix string) *Quad {
+	return q.mapBlankNodes(func(b string) string {
+		return "_:" + prefix + b[2:]
+	})
+}
+
+// wellKnownGenid is the path of skolem IRIs, from RDF 1.1 Concepts, 3.5.
+const wellKnownGenid = "/.well-known/genid/"
+
+// skolemPrefix returns the prefix of skolem IRIs under base.
+func skolemPrefix(base string) string {
+	return strings.TrimSuffix(base, "/") + wellKnownGenid
+}
+
+// Skolemize returns the quad with every blank node replaced by an IRI under
+// base, e.g. _:b0 becomes <http://example.org/.well-known/genid/b0>.
+func Skolemize(q *Quad, base string) *Quad {
+	prefix := skolemPrefix(base)
+	c := *q
+	if strings.HasPrefix(c.Subject, "_:") {
+		c.Subject = prefix + c.Subject[2:]
+	}
+	if c.ObjectKind == BlankNode {
+		c.Object, c.ObjectKind = prefix+c.Object[2:], IRI
+	}
+	if strings.HasPrefix(c.Graph, "_:") {
+		c.Graph = prefix + c.Graph[2:]
+	}
+	return &c
+}
+
+// SkolemizeTerm returns the skolem IRI of a blank node term, e.g. _:b0
+// becomes <http://example.org/.well-known/genid/b0>. Other terms stay as
+// they are.
+func SkolemizeTerm(term, base string) string {
+	if !strings.HasPrefix(term, "_:") {
+		return term
+	}
+	return "<" + skolemPrefix(base) + term[2:] + ">"
+}
+
+// DeskolemizeTerm turns a skolem IRI term under base back into a blank node,
+// like Deskolemize. Other terms stay as they are.
+func DeskolemizeTerm(term, base string) string {
+	if !strings.HasPrefix(term, "<") || !strings.HasSuffix(term, ">") {
+		return term
+	}
+	if b, ok := skolemLabel(term[1:len(term)-1], base); ok {
+		return b
+	}
+	return term
+}
+
+// skolemLabel returns the blank node of a skolem IRI under base, or under any
+// base, if base is empty.
+func skolemLabel(iri, base string) (string, bool) {
+	var rest string
+	if base == "" {
+		i := strings.Index(iri, wellKnownGenid)
+		if i < 0 {
+			return "", false
+		}
+		rest = iri[i+len(wellKnownGenid):]
+	} else {
+		prefix := skolemPrefix(base)
+		if !strings.HasPrefix(iri, prefix) {
+			return "", false
+		}
+		rest = iri[len(prefix):]
+	}
+	if rest == "" || !isBlankNodeLabel(rest) {
+		return "", false
+	}
+	return "_:" + rest, true
+}
+
+// Deskolemize turns skolem IRIs under base back into blank nodes. If base is
+// empty, any IRI with a /.well-known/genid/ path counts as skolem IRI.
+func Deskolemize(q *Quad, base string) *Quad {
+	c := *q
+	if b, ok := skolemLabel(c.Subject, base); ok {
+		c.Subject = b
+	}
+	if c.ObjectKind == IRI {
+		if b, ok := skolemLabel(c.Object, base); ok {
+			c.Object, c.ObjectKind = b, BlankNode
+		}
+	}
+	if b, ok := skolemLabel(c.Graph, base); ok {
+		c.Graph = b
+	}
+	return &c
+}
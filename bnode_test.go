@@ -0,0 +1,100 @@
+package ntto
+
+import (
+	"strings"
+	"testing"
+)
+
+var BlankNodeMapTests = []struct {
+	in         string
+	prefixed   string
+	skolemized string
+}{
+	{`<a> <b> <c> .`, `<a> <b> <c> .`, `<a> <b> <c> .`},
+	{`_:b0 <p> _:b1 _:g .`,
+		`_:f1b0 <p> _:f1b1 _:f1g .`,
+		`<http://ex.org/.well-known/genid/b0> <p> <http://ex.org/.well-known/genid/b1> <http://ex.org/.well-known/genid/g> .`},
+	{`<a> <p> "_:b0" .`, `<a> <p> "_:b0" .`, `<a> <p> "_:b0" .`},
+}
+
+func TestBlankNodeMap(t *testing.T) {
+	for _, tt := range BlankNodeMapTests {
+		q, err := ParseNQuad(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := PrefixBlankNodes(q, "f1").String(); out != tt.prefixed {
+			t.Errorf("PrefixBlankNodes(%s) => %s, want: %s", tt.in, out, tt.prefixed)
+		}
+		s := Skolemize(q, "http://ex.org/")
+		if s.String() != tt.skolemized {
+			t.Errorf("Skolemize(%s) => %s, want: %s", tt.in, s, tt.skolemized)
+		}
+		for _, base := range []string{"http://ex.org", ""} {
+			if out := Deskolemize(s, base); *out != *q {
+				t.Errorf("Deskolemize(%s, %q) => %s, want: %s", s, base, out, tt.in)
+			}
+		}
+	}
+}
+
+var SkolemizeTermTests = []struct {
+	term   string
+	skolem string
+	blank  string
+}{
+	{"_:b0", "<http://ex.org/.well-known/genid/b0>", "_:b0"},
+	{"<a>", "<a>", "<a>"},
+	{`"_:b0"`, `"_:b0"`, `"_:b0"`},
+	{"<http://other/.well-known/genid/x>", "<http://other/.well-known/genid/x>", "<http://other/.well-known/genid/x>"},
+}
+
+func TestSkolemizeTerm(t *testing.T) {
+	for _, tt := range SkolemizeTermTests {
+		s := SkolemizeTerm(tt.term, "http://ex.org/")
+		if s != tt.skolem {
+			t.Errorf("SkolemizeTerm(%s) => %s, want: %s", tt.term, s, tt.skolem)
+		}
+		if out := DeskolemizeTerm(s, "http://ex.org"); out != tt.blank {
+			t.Errorf("DeskolemizeTerm(%s) => %s, want: %s", s, out, tt.blank)
+		}
+	}
+}
+
+func TestDeskolemizeOtherBase(t *testing.T) {
+	q, err := ParseNQuad(`<http://other/.well-known/genid/x> <p> <http://ex.org/.well-known/genid/> .`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := Deskolemize(q, "http://ex.org"); *out != *q {
+		t.Errorf("Deskolemize => %s, want unchanged", out)
+	}
+}
+
+func TestBlankNodeHasherAmbiguous(t *testing.T) {
+	h := NewBlankNodeHasher()
+	for _, line := range []string{`<a> <p> _:x .`, `<a> <p> _:y .`, `<a> <q> _:z .`} {
+		q, err := ParseNQuad(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Add(q)
+	}
+	labels := h.Labels()
+	if labels["_:x"] != "_:tx" || labels["_:y"] != "_:ty" || !strings.HasPrefix(labels["_:z"], "_:h") {
+		t.Errorf("Labels => %v, want x and y kept, z hashed", labels)
+	}
+	// a tied blank node labeled like a hashed one must not take its label
+	h = NewBlankNodeHasher()
+	z := labels["_:z"]
+	for _, line := range []string{`<a> <p> ` + z + ` .`, `<a> <p> _:y .`, `<a> <q> _:z .`} {
+		q, err := ParseNQuad(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Add(q)
+	}
+	if labels := h.Labels(); labels[z] == labels["_:z"] {
+		t.Errorf("Labels => %v, want %s and _:z apart", labels, z)
+	}
+}
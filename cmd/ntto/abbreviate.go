@@ -0,0 +1,163 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/miku/ntto"
+)
+
+// AbbreviateOptions configure how prefixes are replaced.
+type AbbreviateOptions struct {
+	Rules []ntto.Rule
+	// Null is the shortcut of rules that remove their prefix.
+	Null string
+	// Native uses the built-in abbreviator, even if replace or perl exist.
+	Native bool
+	// Report writes hits and savings per rule to stderr, implies Native.
+	Report bool
+	// DumpCommand prints the replace or perl command and exits. It cannot be
+	// combined with Native or Report.
+	DumpCommand bool
+	NumWorkers  int
+}
+
+// externalAbbreviator returns replace or perl, whichever is found first, or
+// an empty string.
+func externalAbbreviator() string {
+	for _, name := range []string{"replace", "perl"} {
+		if _, err := exec.LookPath(name); err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// Abbreviate writes the abbreviated N-Triples of filename to output, or to
+// stdout, if output is empty. It uses replace or perl, if available, and the
+// native abbreviator otherwise.
+func Abbreviate(filename, output string, opts AbbreviateOptions) error {
+	if opts.DumpCommand && (opts.Native || opts.Report) {
+		return fmt.Errorf("-c cannot be combined with -native or -report")
+	}
+	executable := externalAbbreviator()
+	if opts.DumpCommand && executable == "" {
+		return fmt.Errorf("-c needs replace or perl, neither was found")
+	}
+	if opts.Native || opts.Report || executable == "" {
+		abbreviator := ntto.NewAbbreviator(opts.Rules, opts.Null)
+		w, close, err := createOutput(output)
+		if err != nil {
+			return err
+		}
+		if err := MapLines(filename, w, abbreviator.Abbreviate); err != nil {
+			return err
+		}
+		if err := close(); err != nil {
+			return err
+		}
+		if opts.Report {
+			return abbreviator.Report().WriteTable(os.Stderr)
+		}
+		return nil
+	}
+	var command string
+	if executable == "perl" {
+		command = ntto.SedifyNull(opts.Rules, opts.NumWorkers, filename, opts.Null)
+	} else {
+		command = ntto.ReplacifyNull(opts.Rules, filename, opts.Null)
+	}
+	if output != "" {
+		command = fmt.Sprintf("%s > %s", command, output)
+	}
+	if opts.DumpCommand {
+		fmt.Println(command)
+		os.Exit(0)
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// AbbreviateCommand replaces rule prefixes in N-Triples with their shortcuts.
+func AbbreviateCommand(args []string) {
+	fs := flag.NewFlagSet("abbreviate", flag.ExitOnError)
+	dumpCommand := fs.Bool("c", false, "dump constructed replace or perl command and exit")
+	nullValue := fs.String("n", "<NULL>", "string to indicate empty string replacement")
+	native := fs.Bool("native", false, "abbreviate natively instead of using replace or perl")
+	report := fs.Bool("report", false, "abbreviate natively and report hits and savings per rule to stderr")
+	inputFormat := fs.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
+	outFile := fs.String("o", "", "output file to write result to, stdout if empty")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s abbreviate [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	filename, cleanup, err := PrepareInput(fs.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	err = Abbreviate(filename, *outFile, AbbreviateOptions{
+		Rules:       rules,
+		Null:        *nullValue,
+		Native:      *native,
+		Report:      *report,
+		DumpCommand: *dumpCommand,
+		NumWorkers:  *numWorkers,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// ExpandCommand turns abbreviated N-Triples back into full IRIs.
+func ExpandCommand(args []string) {
+	fs := flag.NewFlagSet("expand", flag.ExitOnError)
+	nullValue := fs.String("n", "<NULL>", "string to indicate empty string replacement, these rules are skipped")
+	outFile := fs.String("o", "", "output file to write result to, stdout if empty")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s expand [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	w, close, err := createOutput(*outFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := MapLines(fs.Arg(0), w, ntto.NewExpander(rules, *nullValue).Expand); err != nil {
+		log.Fatalln(err)
+	}
+	if err := close(); err != nil {
+		log.Fatalln(err)
+	}
+}
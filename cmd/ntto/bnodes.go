@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/miku/ntto"
+)
+
+// BlankNodesCommand relabels, skolemizes or deskolemizes blank nodes in one
+// or more N-Triples or N-Quads files and writes the result to stdout.
+func BlankNodesCommand(args []string) {
+	fs := flag.NewFlagSet("bnodes", flag.ExitOnError)
+	relabel := fs.Bool("relabel", false, "prefix blank node labels with a namespace per file")
+	prefix := fs.String("prefix", "", "namespace for -relabel, derived from the file name if empty, numbered for several files, followed by _")
+	skolemize := fs.Bool("skolemize", false, "replace blank nodes with IRIs under -base")
+	deskolemize := fs.Bool("deskolemize", false, "replace IRIs under -base with blank nodes, any /.well-known/genid/ IRI if -base is empty")
+	base := fs.String("base", "", "base IRI for skolem IRIs")
+	ignore := fs.Bool("i", false, "pass through lines that cannot be parsed")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s bnodes [OPTIONS] FILE [FILE ...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 || (!*relabel && !*skolemize && !*deskolemize) {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *skolemize && *deskolemize {
+		log.Fatalln("-skolemize and -deskolemize are exclusive")
+	}
+	if *skolemize && *base == "" {
+		log.Fatalln("-skolemize needs a -base")
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	for i, filename := range fs.Args() {
+		// every namespace ends with _, numbered if several files share one
+		ns := *prefix
+		switch {
+		case ns == "":
+			ns = fmt.Sprintf("f%x", sha1.Sum([]byte(filename)))[:9]
+		case fs.NArg() > 1:
+			ns = fmt.Sprintf("%s%d", ns, i+1)
+		}
+		ns += "_"
+		transform := func(pos int, term string) string {
+			if *relabel && strings.HasPrefix(term, "_:") {
+				term = "_:" + ns + term[2:]
+			}
+			if *skolemize {
+				term = ntto.SkolemizeTerm(term, *base)
+			}
+			// predicates are never blank nodes
+			if *deskolemize && pos != 1 {
+				term = ntto.DeskolemizeTerm(term, *base)
+			}
+			return term
+		}
+		if err := mapTerms(filename, w, transform, *ignore); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+// mapTerms writes every line of a file with its terms transformed by f, see
+// ntto.MapNQuadTerms. Empty lines and comments are dropped.
+func mapTerms(filename string, w io.Writer, f func(pos int, term string) string, ignore bool) error {
+	file, err := openInput(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			out, perr := ntto.MapNQuadTerms(trimmed, f)
+			switch {
+			case perr == nil:
+				if _, err := io.WriteString(w, out+"\n"); err != nil {
+					return err
+				}
+			case ignore:
+				if _, err := io.WriteString(w, trimmed+"\n"); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("%s:%d: %s", filename, n, perr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
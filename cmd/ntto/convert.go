@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/miku/ntto"
+)
+
+// ConvertOptions configure output format and layout of a conversion.
+type ConvertOptions struct {
+	Format    string
+	IndexName string
+	Rules     []ntto.Rule
+	// GroupBySubject merges consecutive triples of a subject, GroupAll sorts
+	// by subject first.
+	GroupBySubject bool
+	GroupAll       bool
+	// SplitSize is the maximum size of an output file in MB, 0 for no split.
+	SplitSize int64
+	SplitName string
+	// ShardBy is subject, predicate, namespace or empty for no sharding.
+	ShardBy    string
+	NumShards  int
+	ShardName  string
+	Gzip       bool
+	NumWorkers int
+	Ignore     bool
+	// Keep selects the triples to convert, all if nil.
+	Keep func(*ntto.Triple) bool
+	// Languages is a comma separated list of languages of literals to keep,
+	// - for untagged ones. With BestLanguage, only the first available one
+	// is kept per subject and predicate.
+	Languages    string
+	BestLanguage bool
+	// Typed writes numeric, boolean and date literals as native JSON values.
+	Typed bool
+	// Shape is the layout of ungrouped json output, s, p and o if nil.
+	Shape *ntto.JSONShape
+}
+
+// ConvertFile converts the N-Triples in filename and writes them to output,
+// or to stdout, if output is empty. Split and shard output goes to files
+// named by their patterns instead.
+func ConvertFile(filename, output string, opts ConvertOptions) error {
+	// a subject split over several bulk documents would index the same id
+	// twice, the later document replacing the earlier one
+	if opts.GroupBySubject && opts.Format == "esbulk" {
+		opts.GroupAll = true
+	}
+	if opts.GroupAll {
+		opts.GroupBySubject = true
+		sorted, err := SortFile(filename)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(sorted)
+		filename = sorted
+	}
+
+	keep := opts.Keep
+	var languages *ntto.LanguageFilter
+	if opts.Languages != "" {
+		languages = ntto.NewLanguageFilter(opts.Languages)
+		keep = func(t *ntto.Triple) bool {
+			return languages.Keep(t) && (opts.Keep == nil || opts.Keep(t))
+		}
+	} else if opts.BestLanguage {
+		return fmt.Errorf("-best-lang needs -lang")
+	}
+	// wrap applies the best language selection, if requested
+	wrap := func(e ntto.Encoder) ntto.Encoder {
+		if opts.BestLanguage {
+			return languages.BestLanguage(e)
+		}
+		return e
+	}
+
+	encoderOptions := ntto.EncoderOptions{
+		Rules:     opts.Rules,
+		IndexName: opts.IndexName,
+		Grouped:   opts.GroupBySubject,
+		Shape:     opts.Shape,
+	}
+	if opts.Typed {
+		typed := ntto.NewTypedLiterals(opts.Rules)
+		encoderOptions.Typed = typed
+		defer func() {
+			if n := typed.Fallbacks(); n > 0 {
+				log.Printf("%d typed literals with invalid lexical form kept as strings", n)
+			}
+		}()
+	}
+
+	if opts.ShardBy != "" {
+		if opts.SplitSize > 0 {
+			return fmt.Errorf("-shard and -split are exclusive")
+		}
+		sharder, err := NewSharder(opts.ShardBy, opts.NumShards, opts.Rules)
+		if err != nil {
+			return err
+		}
+		pool := newShardPool(maxOpenShards)
+		encoder := ntto.NewShardEncoder(sharder, func(key string) (ntto.Encoder, io.Closer, error) {
+			f, err := openShard(pool, opts.ShardName, key, opts.Gzip)
+			if err != nil {
+				return nil, nil, err
+			}
+			e, err := ntto.NewEncoder(opts.Format, f, encoderOptions)
+			return e, f, err
+		})
+		err = Convert(filename, wrap(encoder), opts.NumWorkers, &opts.Ignore, keep)
+		if cerr := encoder.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	var writer io.Writer
+	var close func() error
+	if opts.SplitSize > 0 {
+		if opts.Format != "json" && opts.Format != "esbulk" {
+			return fmt.Errorf("-split only works with json and esbulk output")
+		}
+		sw := ntto.NewSplitWriter(opts.SplitName, opts.SplitSize<<20)
+		writer, close = sw, sw.Close
+	} else {
+		w, c, err := createOutput(output)
+		if err != nil {
+			return err
+		}
+		writer, close = w, c
+	}
+	encoder, err := ntto.NewEncoder(opts.Format, writer, encoderOptions)
+	if err != nil {
+		return err
+	}
+	err = Convert(filename, wrap(encoder), opts.NumWorkers, &opts.Ignore, keep)
+	if cerr := close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ConvertCommand converts N-Triples, Turtle or RDF/XML into JSON, JSON-LD,
+// Elasticsearch bulk requests or N-Triples.
+func ConvertCommand(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	abbreviate := fs.Bool("a", false, "abbreviate IRIs using rules before converting")
+	nullValue := fs.String("n", "<NULL>", "string to indicate empty string replacement")
+	native := fs.Bool("native", false, "abbreviate natively instead of using replace or perl")
+	format := fs.String("f", "json", "output format: json, jsonld, jsonld-expanded, esbulk, nt, msgpack, cbor")
+	indexName := fs.String("index", "ntto", "index name for esbulk output")
+	splitSize := fs.Int64("split", 0, "split json or esbulk output into files of at most N MB")
+	splitName := fs.String("split-name", "ntto-%05d.ndjson", "file name pattern for split output")
+	shardBy := fs.String("shard", "", "split output into shards by subject (hash), predicate or namespace")
+	numShards := fs.Int("shards", 16, "number of shards for -shard subject")
+	shardName := fs.String("shard-name", "ntto-%s.ndjson", "file name pattern for shards, %s is the shard key")
+	compress := fs.Bool("gzip", false, "gzip each shard")
+	groupBySubject := fs.Bool("group-by-subject", false, "merge consecutive triples with the same subject into one JSON document")
+	groupAll := fs.Bool("group-all", false, "sort input by subject first, so all triples of a subject are grouped")
+	languages := fs.String("lang", "", "comma separated languages of literals to keep, - for untagged, all if empty")
+	bestLanguage := fs.Bool("best-lang", false, "keep only the first available -lang language per subject and predicate")
+	typed := fs.Bool("typed", false, "write numeric, boolean and date literals as native JSON values")
+	shapeSpec := fs.String("shape", "", "json layout: default, long, array, an inline template or a template file")
+	ignore := fs.Bool("i", false, "ignore conversion errors")
+	inputFormat := fs.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
+	outFile := fs.String("o", "", "output file to write result to, stdout if empty")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s convert [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	shape, err := LoadShape(*shapeSpec)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	filename, cleanup, err := PrepareInput(fs.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	if *abbreviate {
+		tmp, err := ioutil.TempFile("", "ntto-")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		err = Abbreviate(filename, tmp.Name(), AbbreviateOptions{
+			Rules:      rules,
+			Null:       *nullValue,
+			Native:     *native,
+			NumWorkers: *numWorkers,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		filename = tmp.Name()
+	}
+
+	err = ConvertFile(filename, *outFile, ConvertOptions{
+		Format:         *format,
+		IndexName:      *indexName,
+		Rules:          rules,
+		GroupBySubject: *groupBySubject,
+		GroupAll:       *groupAll,
+		SplitSize:      *splitSize,
+		SplitName:      *splitName,
+		ShardBy:        *shardBy,
+		NumShards:      *numShards,
+		ShardName:      *shardName,
+		Gzip:           *compress,
+		NumWorkers:     *numWorkers,
+		Ignore:         *ignore,
+		Languages:      *languages,
+		BestLanguage:   *bestLanguage,
+		Typed:          *typed,
+		Shape:          shape,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/miku/ntto"
+)
+
+// openInput opens a file for reading, "-" is stdin.
+func openInput(filename string) (*os.File, error) {
+	if filename == "-" {
+		return os.Stdin, nil
+	}
+	return os.Open(filename)
+}
+
+// createOutput creates a buffered output file, or wraps stdout if filename
+// is empty. The returned function flushes and closes.
+func createOutput(filename string) (*bufio.Writer, func() error, error) {
+	if filename == "" {
+		w := bufio.NewWriter(os.Stdout)
+		return w, w.Flush, nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	w := bufio.NewWriter(f)
+	return w, func() error {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		return f.Close()
+	}, nil
+}
+
+// DiffCommand compares two N-Triples or N-Quads files and writes the changes
+// as RDF patch or as separate files for added and removed triples.
+func DiffCommand(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	addedFile := fs.String("added", "", "write added triples as n-triples to this file instead of a patch")
+	removedFile := fs.String("removed", "", "write removed triples as n-triples to this file instead of a patch")
+	bnodes := fs.Bool("bnodes", false, "normalize blank node labels before comparing, keeps all blank nodes in memory")
+	memory := fs.Int64("m", ntto.DefaultMemoryLimit>>20, "memory limit in MB before spilling to temporary files")
+	tempDir := fs.String("T", "", "directory for temporary files, system default if empty")
+	ignore := fs.Bool("i", false, "skip lines that cannot be parsed")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s diff [OPTIONS] OLD NEW\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	old, err := openInput(fs.Arg(0))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer old.Close()
+	new, err := openInput(fs.Arg(1))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer new.Close()
+
+	differ := &ntto.Differ{
+		Sorter: ntto.Sorter{
+			MemoryLimit: *memory << 20,
+			TempDir:     *tempDir,
+			Ignore:      *ignore,
+		},
+		NormalizeBlankNodes: *bnodes,
+	}
+
+	var added, removed int
+	var fn func(bool, *ntto.Quad) error
+	var closers []func() error
+	patch := *addedFile == "" && *removedFile == ""
+	var pw *bufio.Writer
+	if patch {
+		w, close, err := createOutput("")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		pw = w
+		closers = append(closers, close)
+		if _, err := io.WriteString(w, "TX .\n"); err != nil {
+			log.Fatalln(err)
+		}
+		fn = func(a bool, q *ntto.Quad) error {
+			op := "D "
+			if a {
+				op = "A "
+			}
+			_, err := io.WriteString(w, op+q.String()+"\n")
+			return err
+		}
+	} else {
+		// only the requested streams are written
+		writers := map[bool]io.Writer{true: ioutil.Discard, false: ioutil.Discard}
+		for a, filename := range map[bool]string{true: *addedFile, false: *removedFile} {
+			if filename == "" {
+				continue
+			}
+			w, close, err := createOutput(filename)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			writers[a] = w
+			closers = append(closers, close)
+		}
+		fn = func(a bool, q *ntto.Quad) error {
+			_, err := io.WriteString(writers[a], q.String()+"\n")
+			return err
+		}
+	}
+
+	err = differ.Diff(old, new, func(a bool, q *ntto.Quad) error {
+		if a {
+			added++
+		} else {
+			removed++
+		}
+		return fn(a, q)
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if patch {
+		if _, err := io.WriteString(pw, "TC .\n"); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	for _, close := range closers {
+		if err := close(); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	log.Printf("%d added, %d removed", added, removed)
+}
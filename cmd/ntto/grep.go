@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/miku/ntto"
+)
+
+// GrepCommand keeps only the triples matching subject, predicate and object
+// patterns.
+func GrepCommand(args []string) {
+	fs := flag.NewFlagSet("grep", flag.ExitOnError)
+	subject := fs.String("s", "", "subject pattern")
+	predicate := fs.String("p", "", "predicate pattern")
+	object := fs.String("o", "", "object pattern")
+	invert := fs.Bool("v", false, "keep triples that do not match")
+	format := fs.String("f", "nt", "output format: nt, json, jsonld, jsonld-expanded, esbulk, msgpack, cbor")
+	indexName := fs.String("index", "ntto", "index name for esbulk output")
+	groupBySubject := fs.Bool("group-by-subject", false, "merge consecutive triples with the same subject into one JSON document")
+	typed := fs.Bool("typed", false, "write numeric, boolean and date literals as native JSON values")
+	shapeSpec := fs.String("shape", "", "json layout: default, long, array, an inline template or a template file")
+	ignore := fs.Bool("i", false, "ignore conversion errors")
+	inputFormat := fs.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s grep [-s PATTERN] [-p PATTERN] [-o PATTERN] [OPTIONS] FILE\n\n", os.Args[0])
+		fmt.Fprintln(os.Stderr, "Patterns are exact values, prefixes ending in *, CURIEs like gnd:118540238")
+		fmt.Fprintf(os.Stderr, "(resolved with the rules) or regular expressions in slashes, like /^Goethe/.\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	filter := &ntto.Filter{Invert: *invert}
+	for _, p := range []struct {
+		s      string
+		target **ntto.Pattern
+	}{
+		{*subject, &filter.Subject},
+		{*predicate, &filter.Predicate},
+		{*object, &filter.Object},
+	} {
+		if p.s == "" {
+			continue
+		}
+		if *p.target, err = ntto.ParsePattern(p.s, rules); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	shape, err := LoadShape(*shapeSpec)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	filename, cleanup, err := PrepareInput(fs.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	if *format == "nt" {
+		writer := bufio.NewWriter(os.Stdout)
+		defer writer.Flush()
+		if err := GrepLines(filename, writer, *ignore, filter.Match); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	err = ConvertFile(filename, "", ConvertOptions{
+		Format:         *format,
+		IndexName:      *indexName,
+		Rules:          rules,
+		GroupBySubject: *groupBySubject,
+		NumWorkers:     *numWorkers,
+		Ignore:         *ignore,
+		Keep:           filter.Match,
+		Typed:          *typed,
+		Shape:          shape,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// GrepLines writes the lines of an n-triples file whose triple is kept,
+// unchanged, to w.
+func GrepLines(filename string, w io.Writer, ignore bool, keep func(*ntto.Triple) bool) error {
+	file, err := openInput(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			line = strings.TrimSuffix(line, "\n")
+			triple, perr := ntto.ParseNTriple(line)
+			switch {
+			case perr != nil && !ignore:
+				return perr
+			case perr != nil:
+				log.Println(perr)
+			case keep(triple):
+				if _, werr := io.WriteString(w, line+"\n"); werr != nil {
+					return werr
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
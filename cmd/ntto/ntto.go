@@ -1,89 +1,108 @@
 package main
 
 import (
-	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
 	"runtime"
 	"runtime/pprof"
-	"sync"
-	"time"
 
 	"github.com/miku/ntto"
 )
 
-func Worker(queue chan *string, out chan *ntto.Triple, wg *sync.WaitGroup, ignore *bool) {
-	defer wg.Done()
-	for b := range queue {
-		triple, err := ntto.ParseNTriple(*b)
-		if err != nil {
-			if !*ignore {
-				log.Fatalln(err)
-			} else {
-				log.Println(err)
-			}
-		}
-		out <- triple
-	}
+// command is a subcommand of ntto.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
 }
 
-func Marshaller(writer io.Writer, in chan *ntto.Triple, done chan bool, ignore *bool) {
-	for triple := range in {
-		b, err := json.Marshal(triple)
-		if err != nil {
-			if !*ignore {
-				log.Fatalln(err)
-			} else {
-				log.Println(err)
-			}
-		}
-		writer.Write(b)
-		writer.Write([]byte("\n"))
-	}
-	done <- true
+var commands = []command{
+	{"abbreviate", "replace IRI prefixes with shortcuts", AbbreviateCommand},
+	{"convert", "convert to json, jsonld, esbulk or n-triples", ConvertCommand},
+	{"expand", "turn abbreviated IRIs back into full IRIs", ExpandCommand},
+	{"rules", "dump, lint or suggest rules", RulesCommand},
+	{"grep", "filter triples by subject, predicate or object", GrepCommand},
+	{"stats", "count predicates, namespaces, languages and datatypes", StatsCommand},
+	{"validate", "check n-triples for syntax and lexical errors", ValidateCommand},
+	{"void", "describe a dataset with VoID", VoIDCommand},
+	{"sort", "sort and deduplicate n-triples or n-quads", SortCommand},
+	{"diff", "compare two n-triples files", DiffCommand},
+	{"bnodes", "relabel, skolemize or deskolemize blank nodes", BlankNodesCommand},
+	{"wikidata", "filter Wikidata dumps, extract terms by language", WikidataCommand},
+	{"serve", "convert, abbreviate and validate over HTTP", ServeCommand},
 }
 
-func main() {
-
-	executable := "replace"
-	_, err := exec.LookPath("replace")
-	if err != nil {
-		executable = "perl"
+// printUsage lists the commands, followed by the flags that still work
+// without a command.
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s COMMAND [OPTIONS] FILE\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s%s\n", c.name, c.summary)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s help COMMAND' for the options of a command.\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "\nWithout a command, the following flags are understood, for compatibility:\n\n")
+	fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] FILE\n", os.Args[0])
+	flag.PrintDefaults()
+}
 
-	_, err = exec.LookPath("perl")
-	if err != nil {
-		log.Fatalln("This program requires perl or replace.")
-		os.Exit(1)
+func main() {
+	if len(os.Args) > 1 {
+		name, args := os.Args[1], os.Args[2:]
+		if name == "help" {
+			if len(args) == 0 {
+				// show the full usage, including the legacy flags
+				os.Args = []string{os.Args[0], "-h"}
+				legacyMain()
+				return
+			}
+			name, args = args[0], []string{"-h"}
+		}
+		for _, c := range commands {
+			if c.name == name {
+				c.run(args)
+				return
+			}
+		}
 	}
+	legacyMain()
+}
 
+// legacyMain implements the original single command interface, where -a
+// abbreviates and -j or -f convert, or both, in this order.
+func legacyMain() {
 	abbreviate := flag.Bool("a", false, "abbreviate n-triples using rules")
 	cpuprofile := flag.String("cpuprofile", "", "write cpu profile to file")
 	dumpCommand := flag.Bool("c", false, "dump constructed sed command and exit")
 	dumpRules := flag.Bool("d", false, "dump rules and exit")
 	ignore := flag.Bool("i", false, "ignore conversion errors")
+	inputFormat := flag.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
 	jsonOutput := flag.Bool("j", false, "convert nt to json")
+	format := flag.String("f", "json", "output format: json, jsonld, jsonld-expanded, esbulk, nt")
+	indexName := flag.String("index", "ntto", "index name for esbulk output")
+	splitSize := flag.Int64("split", 0, "split json or esbulk output into files of at most N MB")
+	splitName := flag.String("split-name", "ntto-%05d.ndjson", "file name pattern for split output")
+	shardBy := flag.String("shard", "", "split output into shards by subject (hash), predicate or namespace")
+	numShards := flag.Int("shards", 16, "number of shards for -shard subject")
+	shardName := flag.String("shard-name", "ntto-%s.ndjson", "file name pattern for shards, %s is the shard key")
+	compress := flag.Bool("gzip", false, "gzip each shard")
+	groupBySubject := flag.Bool("group-by-subject", false, "merge consecutive triples with the same subject into one JSON document")
+	groupAll := flag.Bool("group-all", false, "sort input by subject first, so all triples of a subject are grouped")
 	nullValue := flag.String("n", "<NULL>", "string to indicate empty string replacement")
+	native := flag.Bool("native", false, "abbreviate natively instead of using replace or perl")
+	report := flag.Bool("report", false, "abbreviate natively and report hits and savings per rule to stderr")
 	outFile := flag.String("o", "", "output file to write result to")
-	rulesFile := flag.String("r", "", "path to rules file, use built-in if none given")
+	rulesFile := flag.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
 	version := flag.Bool("v", false, "prints current version and exits")
 	numWorkers := flag.Int("w", runtime.NumCPU(), "parallelism measure")
 
+	flag.Usage = printUsage
 	flag.Parse()
 
 	runtime.GOMAXPROCS(*numWorkers)
 
-	var PrintUsage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [OPTIONS] FILE\n", os.Args[0])
-		flag.PrintDefaults()
-	}
-
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -98,60 +117,51 @@ func main() {
 		os.Exit(0)
 	}
 
-	var rules []ntto.Rule
-
-	if *rulesFile == "" {
-		rules, err = ntto.ParseRules(ntto.DefaultRules)
-		if err != nil {
-			log.Fatalln(err)
-		}
-	} else {
-		b, err := ioutil.ReadFile(*rulesFile)
-		if err != nil {
-			log.Fatalln(err)
-		}
-		rules, err = ntto.ParseRules(string(b))
-		if err != nil {
-			log.Fatalln(err)
-		}
+	layers, err := LoadRuleLayers(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
 	}
+	layered := ntto.MergeRuleLayers(layers)
 
 	if *dumpRules {
-		fmt.Println(ntto.DumpRules(rules))
+		fmt.Println(ntto.DumpRuleLayers(layered))
 		os.Exit(0)
 	}
+	rules := ntto.Rules(layered)
 
 	if flag.NArg() < 1 {
-		PrintUsage()
+		printUsage()
 		os.Exit(1)
 	}
 
-	filename := flag.Args()[0]
 	var output string
 
+	filename, cleanup, err := PrepareInput(flag.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
 	if *abbreviate {
 		if *outFile == "" {
 			tmp, err := ioutil.TempFile("", "ntto-")
-			output = tmp.Name()
-			log.Printf("No explicit [-o]utput given, writing to %s\n", output)
 			if err != nil {
 				log.Fatalln(err)
 			}
+			tmp.Close()
+			output = tmp.Name()
+			log.Printf("No explicit [-o]utput given, writing to %s\n", output)
 		} else {
 			output = *outFile
 		}
-
-		var command string
-		if executable == "perl" {
-			command = fmt.Sprintf("%s > %s", ntto.SedifyNull(rules, *numWorkers, filename, *nullValue), output)
-		} else {
-			command = fmt.Sprintf("%s > %s", ntto.ReplacifyNull(rules, filename, *nullValue), output)
-		}
-		if *dumpCommand {
-			fmt.Println(command)
-			os.Exit(0)
-		}
-		_, err = exec.Command("sh", "-c", command).Output()
+		err := Abbreviate(filename, output, AbbreviateOptions{
+			Rules:       rules,
+			Null:        *nullValue,
+			Native:      *native,
+			Report:      *report,
+			DumpCommand: *dumpCommand,
+			NumWorkers:  *numWorkers,
+		})
 		if err != nil {
 			log.Fatalln(err)
 		}
@@ -159,53 +169,27 @@ func main() {
 		filename = output
 	}
 
-	if *jsonOutput {
-		var file *os.File
-		if filename == "-" {
-			file = os.Stdin
-		} else {
-			file, err = os.Open(filename)
-			defer file.Close()
-			if err != nil {
-				log.Fatalln(err)
-			}
-		}
-
-		queue := make(chan *string)
-		results := make(chan *ntto.Triple)
-		done := make(chan bool)
-
-		writer := bufio.NewWriter(os.Stdout)
-		defer writer.Flush()
-		go Marshaller(writer, results, done, ignore)
-
-		var wg sync.WaitGroup
-		for i := 0; i < *numWorkers; i++ {
-			wg.Add(1)
-			go Worker(queue, results, &wg, ignore)
-		}
-
-		reader := bufio.NewReader(file)
-
-		for {
-			b, _, err := reader.ReadLine()
-			if err != nil || b == nil {
-				break
-			}
-			line := string(b)
-			queue <- &line
-		}
-		close(queue)
-		wg.Wait()
-		close(results)
-		select {
-		case <-time.After(1e9):
-			break
-		case <-done:
-			break
+	if *jsonOutput || *format != "json" || *groupBySubject || *groupAll || *shardBy != "" {
+		err := ConvertFile(filename, "", ConvertOptions{
+			Format:         *format,
+			IndexName:      *indexName,
+			Rules:          rules,
+			GroupBySubject: *groupBySubject,
+			GroupAll:       *groupAll,
+			SplitSize:      *splitSize,
+			SplitName:      *splitName,
+			ShardBy:        *shardBy,
+			NumShards:      *numShards,
+			ShardName:      *shardName,
+			Gzip:           *compress,
+			NumWorkers:     *numWorkers,
+			Ignore:         *ignore,
+		})
+		if err != nil {
+			log.Fatalln(err)
 		}
 		// remove abbreviated tempfile output, if possible
-		if *outFile == "" {
+		if *abbreviate && *outFile == "" {
 			_ = os.Remove(output)
 		}
 	}
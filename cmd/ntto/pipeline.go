@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/miku/ntto"
+)
+
+// batchSize is the number of lines handed to a worker at once.
+const batchSize = 10000
+
+// Batch is a chunk of input lines. Batches are numbered, so the output can
+// keep the input order, which grouping output formats rely on.
+type Batch struct {
+	Seq     int
+	Lines   []string
+	Triples []*ntto.Triple
+}
+
+// Worker parses the lines of each batch. If keep is not nil, only triples for
+// which keep returns true are passed on.
+func Worker(queue chan *Batch, out chan *Batch, wg *sync.WaitGroup, ignore *bool, keep func(*ntto.Triple) bool) {
+	defer wg.Done()
+	for b := range queue {
+		for i, line := range b.Lines {
+			triple, err := ntto.ParseNTriple(line)
+			if err != nil {
+				if !*ignore {
+					log.Fatalln(err)
+				} else {
+					log.Println(err)
+				}
+				continue
+			}
+			triple.Line = b.Seq*batchSize + i + 1
+			if keep != nil && !keep(triple) {
+				continue
+			}
+			b.Triples = append(b.Triples, triple)
+		}
+		out <- b
+	}
+}
+
+// Marshaller encodes the triples of all batches in input order.
+func Marshaller(encoder ntto.Encoder, in chan *Batch, done chan bool, ignore *bool) {
+	pending := make(map[int]*Batch)
+	next := 0
+	for b := range in {
+		pending[b.Seq] = b
+		for {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			for _, triple := range b.Triples {
+				if err := encoder.Encode(triple); err != nil {
+					if !*ignore {
+						log.Fatalln(err)
+					} else {
+						log.Println(err)
+					}
+				}
+			}
+		}
+	}
+	if err := encoder.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+	done <- true
+}
+
+// ToNTriples converts a Turtle or RDF/XML file into a temporary N-Triples
+// file and returns its name, so all modes can work on it.
+func ToNTriples(filename, format string) (string, error) {
+	var file *os.File
+	var err error
+	if filename == "-" {
+		file = os.Stdin
+	} else {
+		file, err = os.Open(filename)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+	}
+	var parser ntto.TripleReader
+	switch format {
+	case "ttl":
+		parser = ntto.NewTurtleParser(bufio.NewReader(file))
+	case "rdfxml":
+		parser = ntto.NewRDFXMLParser(bufio.NewReader(file), "")
+	case "msgpack":
+		parser = ntto.NewMessagePackReader(file)
+	case "cbor":
+		parser = ntto.NewCBORReader(file)
+	default:
+		return "", fmt.Errorf("unknown input format: %s", format)
+	}
+	tmp, err := ioutil.TempFile("", "ntto-")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+	writer := bufio.NewWriter(tmp)
+	for {
+		triple, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return "", err
+		}
+		writer.WriteString(triple.String())
+		writer.WriteString("\n")
+	}
+	if err := writer.Flush(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+// SortFile sorts an N-Triples file by subject into a temporary file and
+// returns its name.
+func SortFile(filename string) (string, error) {
+	tmp, err := ioutil.TempFile("", "ntto-")
+	if err != nil {
+		return "", err
+	}
+	defer tmp.Close()
+	var cmd *exec.Cmd
+	if filename == "-" {
+		cmd = exec.Command("sh", "-c", ntto.SortBySubject(""))
+		cmd.Stdin = os.Stdin
+	} else {
+		cmd = exec.Command("sh", "-c", ntto.SortBySubject(filename))
+	}
+	cmd.Stdout = tmp
+	cmd.Stderr = os.Stderr
+	return tmp.Name(), cmd.Run()
+}
+
+// Convert reads N-Triples from filename, "-" for stdin, and encodes all
+// triples kept by keep, which may be nil.
+func Convert(filename string, encoder ntto.Encoder, numWorkers int, ignore *bool, keep func(*ntto.Triple) bool) error {
+	var file *os.File
+	if filename == "-" {
+		file = os.Stdin
+	} else {
+		var err error
+		file, err = os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+	}
+
+	queue := make(chan *Batch)
+	results := make(chan *Batch)
+	done := make(chan bool)
+
+	go Marshaller(encoder, results, done, ignore)
+
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go Worker(queue, results, &wg, ignore, keep)
+	}
+
+	reader := bufio.NewReader(file)
+
+	batch := &Batch{}
+	for {
+		b, _, err := reader.ReadLine()
+		if err != nil || b == nil {
+			break
+		}
+		batch.Lines = append(batch.Lines, string(b))
+		if len(batch.Lines) == batchSize {
+			queue <- batch
+			batch = &Batch{Seq: batch.Seq + 1}
+		}
+	}
+	queue <- batch
+	close(queue)
+	wg.Wait()
+	close(results)
+	<-done
+	return nil
+}
+
+// MapLines writes every line of filename, "-" for stdin, to w, with f
+// applied to it.
+func MapLines(filename string, w io.Writer, f func(string) string) error {
+	file, err := openInput(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			if _, werr := io.WriteString(w, f(strings.TrimSuffix(line, "\n"))+"\n"); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// LoadRules resolves a rules spec, as described for LoadRuleLayers, and
+// returns the merged rules.
+func LoadRules(spec string) ([]ntto.Rule, error) {
+	layers, err := LoadRuleLayers(spec)
+	if err != nil {
+		return nil, err
+	}
+	return ntto.Rules(ntto.MergeRuleLayers(layers)), nil
+}
+
+// LoadShape returns the JSON shape for a spec: the name of a built-in shape,
+// an inline template starting with { or [, or a template file. An empty spec
+// is no shape.
+func LoadShape(spec string) (*ntto.JSONShape, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	for _, name := range ntto.BuiltinShapes() {
+		if spec == name {
+			return ntto.ParseJSONShape(spec)
+		}
+	}
+	if !strings.HasPrefix(spec, "{") && !strings.HasPrefix(spec, "[") {
+		b, err := ioutil.ReadFile(spec)
+		if err != nil {
+			return nil, err
+		}
+		spec = string(b)
+	}
+	return ntto.ParseJSONShape(spec)
+}
+
+// LoadRuleLayers resolves a comma separated list of profiles and rule files,
+// like dbpedia,gnd,+local.rules, into layers. Names are looked up in the
+// profile directory first, then among the built-in profiles. Elements
+// starting with + are files, as are names that are no profile, so a single
+// rules file works as before. An empty spec is the default profile.
+func LoadRuleLayers(spec string) ([]ntto.RuleLayer, error) {
+	if spec == "" {
+		spec = "default"
+	}
+	var layers []ntto.RuleLayer
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.HasPrefix(name, "+") {
+			layer, err := loadRuleFile(name[1:])
+			if err != nil {
+				return nil, err
+			}
+			layers = append(layers, layer)
+			continue
+		}
+		if dir := profileDir(); dir != "" && !strings.ContainsAny(name, `/\`) {
+			filename := filepath.Join(dir, name+".rules")
+			if _, err := os.Stat(filename); err == nil {
+				layer, err := loadRuleFile(filename)
+				if err != nil {
+					return nil, err
+				}
+				layer.Name = name + " (" + filename + ")"
+				layers = append(layers, layer)
+				continue
+			}
+		}
+		if name == "default" {
+			// one layer per pack, so dumps show where each rule came from
+			for _, pack := range ntto.Packs {
+				rules, _ := ntto.BuiltinProfile(pack)
+				layers = append(layers, ntto.RuleLayer{Name: pack, Rules: rules})
+			}
+			continue
+		}
+		if rules, ok := ntto.BuiltinProfile(name); ok {
+			layers = append(layers, ntto.RuleLayer{Name: name, Rules: rules})
+			continue
+		}
+		layer, err := loadRuleFile(name)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no rules file or profile named %s, built-in profiles are: %s",
+				name, strings.Join(ntto.BuiltinProfiles(), ", "))
+		}
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// loadRuleFile reads a rules file into a layer named after the file.
+func loadRuleFile(filename string) (ntto.RuleLayer, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return ntto.RuleLayer{}, err
+	}
+	rules, err := ntto.ParseRules(string(b))
+	if err != nil {
+		return ntto.RuleLayer{}, fmt.Errorf("%s: %v", filename, err)
+	}
+	return ntto.RuleLayer{Name: filename, Rules: rules}, nil
+}
+
+// profileDir returns the directory of user defined profiles, named
+// NAME.rules: $NTTO_PROFILE_DIR, or ntto/rules in the user config directory.
+func profileDir() string {
+	if dir := os.Getenv("NTTO_PROFILE_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "ntto", "rules")
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".config", "ntto", "rules")
+	}
+	return ""
+}
+
+// PrepareInput converts Turtle or RDF/XML input to N-Triples, if necessary.
+// The format is guessed from the file extension, if empty. The returned
+// cleanup function removes any temporary file.
+func PrepareInput(filename, format string) (string, func(), error) {
+	if format == "" {
+		switch filepath.Ext(filename) {
+		case ".ttl":
+			format = "ttl"
+		case ".rdf", ".owl", ".xml":
+			format = "rdfxml"
+		case ".msgpack":
+			format = "msgpack"
+		case ".cbor":
+			format = "cbor"
+		}
+	}
+	if format == "" || format == "nt" {
+		return filename, func() {}, nil
+	}
+	converted, err := ToNTriples(filename, format)
+	if err != nil {
+		return "", nil, err
+	}
+	return converted, func() { os.Remove(converted) }, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/miku/ntto"
+)
+
+// RulesCommand groups the commands working on rules.
+func RulesCommand(args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s rules dump|lint|suggest [OPTIONS]\n", os.Args[0])
+		os.Exit(1)
+	}
+	if len(args) < 1 {
+		usage()
+	}
+	switch args[0] {
+	case "dump":
+		RulesDumpCommand(args[1:])
+	case "lint":
+		RulesLintCommand(args[1:])
+	case "suggest":
+		RulesSuggestCommand(args[1:])
+	default:
+		usage()
+	}
+}
+
+// RulesDumpCommand prints the rules in use, grouped by the profile or file
+// they came from.
+func RulesDumpCommand(args []string) {
+	fs := flag.NewFlagSet("rules dump", flag.ExitOnError)
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s rules dump [OPTIONS]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	layers, err := LoadRuleLayers(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	fmt.Println(ntto.DumpRuleLayers(ntto.MergeRuleLayers(layers)))
+}
+
+// RulesLintCommand checks rules files, the built-in rules if none is given,
+// and exits with a non-zero status on errors.
+func RulesLintCommand(args []string) {
+	fs := flag.NewFlagSet("rules lint", flag.ExitOnError)
+	nullValue := fs.String("n", "<NULL>", "string to indicate empty string replacement")
+	strict := fs.Bool("strict", false, "treat warnings as errors")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s rules lint [OPTIONS] [FILE ...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	names := fs.Args()
+	if len(names) == 0 {
+		names = []string{"built-in"}
+	}
+	failed := false
+	for _, name := range names {
+		var problems []ntto.Problem
+		if fs.NArg() == 0 {
+			problems = ntto.LintDefaultRules(*nullValue)
+		} else {
+			b, err := ioutil.ReadFile(name)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			problems = ntto.LintRules(string(b), *nullValue)
+		}
+		for _, p := range problems {
+			fmt.Printf("%s:%s\n", name, p)
+			if p.Severity == ntto.SeverityError || *strict {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// RulesSuggestCommand writes a rules file for the frequent namespaces of a
+// file, ranked by bytes saved.
+func RulesSuggestCommand(args []string) {
+	fs := flag.NewFlagSet("rules suggest", flag.ExitOnError)
+	limit := fs.Int("n", 50, "maximum number of rules, 0 for no limit")
+	min := fs.Int64("min", 100, "minimum number of IRIs per namespace")
+	max := fs.Int("max", 10000, "maximum number of distinct namespaces kept while counting")
+	ignore := fs.Bool("i", false, "ignore conversion errors")
+	inputFormat := fs.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE) to reuse shortcuts from")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s rules suggest [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	filename, cleanup, err := PrepareInput(fs.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	suggester := ntto.NewRuleSuggester(rules, *max)
+	if err := Convert(filename, suggester, *numWorkers, ignore, nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	writer := bufio.NewWriter(os.Stdout)
+	defer writer.Flush()
+	fmt.Fprintf(writer, "# rules suggested for %s, ranked by bytes saved\n", fs.Arg(0))
+	for _, s := range suggester.Suggest(*limit, *min) {
+		fmt.Fprintln(writer, s)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/miku/ntto"
+)
+
+// ServeCommand runs an HTTP service for conversion, abbreviation, expansion
+// and validation of small N-Triples payloads.
+func ServeCommand(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to listen on")
+	indexName := fs.String("index", "ntto", "default index name for esbulk output")
+	maxBodySize := fs.Int64("max-body", ntto.DefaultMaxBodySize>>20, "maximum request body size in MB")
+	nullValue := fs.String("n", "<NULL>", "string to indicate empty string replacement")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s serve [OPTIONS]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	server := &ntto.Server{
+		Rules:       rules,
+		Null:        *nullValue,
+		IndexName:   *indexName,
+		MaxBodySize: *maxBodySize << 20,
+	}
+	log.Printf("listening on %s", *addr)
+	log.Fatalln(http.ListenAndServe(*addr, server))
+}
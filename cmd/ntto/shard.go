@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"container/list"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/miku/ntto"
+)
+
+// NewSharder returns the sharder for subject, predicate or namespace
+// sharding. Subject sharding uses n shards.
+func NewSharder(by string, n int, rules []ntto.Rule) (ntto.Sharder, error) {
+	switch by {
+	case "subject":
+		return ntto.SubjectHashSharder(n), nil
+	case "predicate":
+		return ntto.PredicateSharder(rules), nil
+	case "namespace":
+		return ntto.NamespaceSharder(rules), nil
+	}
+	return nil, fmt.Errorf("unknown shard mode: %s", by)
+}
+
+// maxOpenShards is the number of shard files kept open at the same time.
+// Beyond that, the least recently written shard is closed and reopened for
+// appending on its next write, so many shards do not run out of file
+// descriptors.
+const maxOpenShards = 128
+
+// shardPool tracks the open shard files, most recently written first.
+type shardPool struct {
+	limit int
+	open  *list.List
+}
+
+func newShardPool(limit int) *shardPool {
+	return &shardPool{limit: limit, open: list.New()}
+}
+
+// shardFile is a buffered, optionally compressed shard output file, which is
+// opened on demand. A compressed shard reopened for appending gets another
+// gzip member, which gzip readers concatenate.
+type shardFile struct {
+	w        *bufio.Writer
+	name     string
+	compress bool
+	created  bool
+	pool     *shardPool
+	elem     *list.Element
+	gz       *gzip.Writer
+	f        *os.File
+}
+
+// Write writes p to the shard, reopening the file if needed.
+func (s *shardFile) Write(p []byte) (int, error) {
+	if s.f == nil {
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	} else {
+		s.pool.open.MoveToFront(s.elem)
+	}
+	return s.w.Write(p)
+}
+
+// open opens the file, truncating it the first time, closing the least
+// recently written shard, if too many are open.
+func (s *shardFile) open() error {
+	for s.pool.open.Len() >= s.pool.limit {
+		if err := s.pool.open.Back().Value.(*shardFile).Close(); err != nil {
+			return err
+		}
+	}
+	flag := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if !s.created {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(s.name, flag, 0644)
+	if err != nil {
+		return err
+	}
+	s.f, s.created = f, true
+	var w io.Writer = f
+	if s.compress {
+		s.gz = gzip.NewWriter(f)
+		w = s.gz
+	}
+	s.w = bufio.NewWriter(w)
+	s.elem = s.pool.open.PushFront(s)
+	return nil
+}
+
+// Close flushes the buffer and closes compressor and file.
+func (s *shardFile) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	s.pool.open.Remove(s.elem)
+	f := s.f
+	s.f, s.elem = nil, nil
+	if err := s.w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if s.gz != nil {
+		if err := s.gz.Close(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// openShard creates the file for a shard, named by a pattern containing %s.
+func openShard(pool *shardPool, pattern, key string, compress bool) (*shardFile, error) {
+	name := fmt.Sprintf(pattern, key)
+	if compress && !strings.HasSuffix(name, ".gz") {
+		name += ".gz"
+	}
+	s := &shardFile{name: name, compress: compress, pool: pool}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
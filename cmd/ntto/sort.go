@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/miku/ntto"
+)
+
+// SortCommand sorts N-Triples or N-Quads with an external merge sort,
+// optionally dropping duplicates.
+func SortCommand(args []string) {
+	fs := flag.NewFlagSet("sort", flag.ExitOnError)
+	unique := fs.Bool("u", false, "drop duplicate triples")
+	order := fs.String("order", "spo", "sort order: spo, pos or osp")
+	memory := fs.Int64("m", ntto.DefaultMemoryLimit>>20, "memory limit in MB before spilling to temporary files")
+	tempDir := fs.String("T", "", "directory for temporary files, system default if empty")
+	ignore := fs.Bool("i", false, "skip lines that cannot be parsed")
+	outFile := fs.String("o", "", "output file to write result to, stdout if empty")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s sort [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	o, err := ntto.ParseOrder(*order)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	in := os.Stdin
+	if fs.Arg(0) != "-" {
+		if in, err = os.Open(fs.Arg(0)); err != nil {
+			log.Fatalln(err)
+		}
+		defer in.Close()
+	}
+	out := os.Stdout
+	if *outFile != "" {
+		if out, err = os.Create(*outFile); err != nil {
+			log.Fatalln(err)
+		}
+		defer out.Close()
+	}
+
+	sorter := &ntto.Sorter{
+		Order:       o,
+		Unique:      *unique,
+		MemoryLimit: *memory << 20,
+		TempDir:     *tempDir,
+		Ignore:      *ignore,
+	}
+	if err := sorter.Sort(in, out); err != nil {
+		log.Fatalln(err)
+	}
+}
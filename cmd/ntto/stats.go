@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/miku/ntto"
+)
+
+// StatsCommand reports counts per predicate, namespace, object kind, language
+// and datatype, plus estimated savings per rule.
+func StatsCommand(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "write report as JSON")
+	top := fs.Int("top", 25, "number of entries per table, 0 for all kept")
+	max := fs.Int("max", 10000, "maximum number of distinct keys kept per counter")
+	ignore := fs.Bool("i", false, "ignore conversion errors")
+	inputFormat := fs.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s stats [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	filename, cleanup, err := PrepareInput(fs.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	stats := ntto.NewStats(rules, *max)
+	if err := Convert(filename, stats, *numWorkers, ignore, nil); err != nil {
+		log.Fatalln(err)
+	}
+	report := stats.Report(*top)
+	if *jsonOutput {
+		if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	if err := report.WriteTable(os.Stdout); err != nil {
+		log.Fatalln(err)
+	}
+}
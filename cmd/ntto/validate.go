@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/miku/ntto"
+)
+
+// errTooManyProblems stops validation once the limit is reached.
+var errTooManyProblems = errors.New("too many problems")
+
+// fileProblem is a problem found in a named file, for JSON output.
+type fileProblem struct {
+	File string `json:"file"`
+	ntto.Problem
+}
+
+// ValidateCommand checks N-Triples files line by line and exits with a
+// non-zero status on errors, so it can gate data releases in CI.
+func ValidateCommand(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "write one JSON object per problem")
+	strict := fs.Bool("strict", false, "treat warnings as errors")
+	max := fs.Int("max", 0, "stop after this many problems per file, 0 for no limit")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s validate [OPTIONS] FILE [FILE ...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	failed := false
+	for _, filename := range fs.Args() {
+		file := os.Stdin
+		if filename != "-" {
+			var err error
+			file, err = os.Open(filename)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
+		count := 0
+		summary, err := ntto.Validate(file, func(p ntto.Problem) error {
+			var err error
+			if *jsonOutput {
+				err = encoder.Encode(fileProblem{File: filename, Problem: p})
+			} else {
+				_, err = fmt.Fprintf(w, "%s:%s\n", filename, p)
+			}
+			if err != nil {
+				return err
+			}
+			count++
+			if *max > 0 && count >= *max {
+				return errTooManyProblems
+			}
+			return nil
+		})
+		file.Close()
+		if err != nil && err != errTooManyProblems {
+			log.Fatalln(err)
+		}
+		if err := w.Flush(); err != nil {
+			log.Fatalln(err)
+		}
+		if err == errTooManyProblems {
+			log.Printf("%s: stopped after %d problems", filename, count)
+		}
+		log.Printf("%s: %d lines, %d errors, %d warnings", filename, summary.Lines, summary.Errors, summary.Warnings)
+		if summary.Errors > 0 || (*strict && summary.Warnings > 0) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
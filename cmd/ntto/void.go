@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+
+	"github.com/miku/ntto"
+)
+
+// VoIDCommand writes a VoID description of a dataset, computed in one pass.
+func VoIDCommand(args []string) {
+	fs := flag.NewFlagSet("void", flag.ExitOnError)
+	dataset := fs.String("dataset", "http://example.org/dataset", "IRI of the described dataset")
+	format := fs.String("f", "ttl", "output format: ttl or nt")
+	max := fs.Int("max", 10000, "maximum number of property and class partitions kept")
+	precision := fs.Uint("precision", 14, "HyperLogLog precision for distinct counts, 4-18")
+	ignore := fs.Bool("i", false, "ignore conversion errors")
+	inputFormat := fs.String("in", "", "input format: nt, ttl, rdfxml, msgpack or cbor, guessed from file extension if not given")
+	rulesFile := fs.String("r", "", "comma separated rule profiles or files (+FILE) for prefixes")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s void [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *format != "ttl" && *format != "nt" {
+		log.Fatalf("unknown output format: %s", *format)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	filename, cleanup, err := PrepareInput(fs.Arg(0), *inputFormat)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer cleanup()
+
+	void := ntto.NewVoID(*max, uint8(*precision))
+	if err := Convert(filename, void, *numWorkers, ignore, nil); err != nil {
+		log.Fatalln(err)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	triples := void.Description(*dataset)
+	if *format == "ttl" {
+		if err := ntto.WriteTurtle(w, triples, rules); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	encoder := ntto.NewNTriplesEncoder(w)
+	for _, t := range triples {
+		if err := encoder.Encode(t); err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/miku/ntto"
+)
+
+// WikidataCommand converts Wikidata dumps, optionally reduced to the truthy
+// statements, or extracts labels, descriptions and aliases by language.
+func WikidataCommand(args []string) {
+	fs := flag.NewFlagSet("wikidata", flag.ExitOnError)
+	abbreviate := fs.Bool("a", false, "abbreviate IRIs using rules before converting")
+	native := fs.Bool("native", false, "abbreviate natively instead of using replace or perl")
+	truthy := fs.Bool("truthy", false, "keep only truthy wdt: statements, drop statement, qualifier and reference nodes")
+	terms := fs.Bool("terms", false, "write labels, descriptions and aliases per entity, keyed by language")
+	languages := fs.String("lang", "", "comma separated languages of labels, descriptions and aliases to keep, all if empty")
+	format := fs.String("f", "nt", "output format: json, jsonld, jsonld-expanded, esbulk, nt, msgpack, cbor, ignored with -terms")
+	indexName := fs.String("index", "wikidata", "index name for esbulk output")
+	ignore := fs.Bool("i", false, "ignore conversion errors")
+	outFile := fs.String("o", "", "output file to write result to, stdout if empty")
+	rulesFile := fs.String("r", "wikidata", "comma separated rule profiles or files (+FILE), later ones override earlier ones")
+	numWorkers := fs.Int("w", runtime.NumCPU(), "parallelism measure")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s wikidata [OPTIONS] FILE\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	rules, err := LoadRules(*rulesFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	filter := ntto.NewWikidataFilter(rules)
+	filter.Truthy = *truthy
+	if *languages != "" {
+		filter.Languages = strings.Split(*languages, ",")
+	}
+
+	filename := fs.Arg(0)
+	if *abbreviate {
+		tmp, err := ioutil.TempFile("", "ntto-")
+		if err != nil {
+			log.Fatalln(err)
+		}
+		tmp.Close()
+		defer os.Remove(tmp.Name())
+		err = Abbreviate(filename, tmp.Name(), AbbreviateOptions{
+			Rules:      rules,
+			Null:       "<NULL>",
+			Native:     *native,
+			NumWorkers: *numWorkers,
+		})
+		if err != nil {
+			log.Fatalln(err)
+		}
+		filename = tmp.Name()
+	}
+
+	if *terms {
+		w, close, err := createOutput(*outFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		encoder := ntto.NewWikidataTermEncoder(w, rules, filter.Languages)
+		err = Convert(filename, encoder, *numWorkers, ignore, filter.Keep)
+		if cerr := close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+
+	err = ConvertFile(filename, *outFile, ConvertOptions{
+		Format:     *format,
+		IndexName:  *indexName,
+		Rules:      rules,
+		NumWorkers: *numWorkers,
+		Ignore:     *ignore,
+		Keep:       filter.Keep,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -7,16 +7,70 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 const AppVersion = "0.4.2"
 
+// Kind tells what sort of RDF term an object is.
+type Kind int
+
+const (
+	IRI Kind = iota
+	Literal
+	BlankNode
+)
+
+// Triple holds subject, predicate and object without their N-Triples
+// decoration. Literal objects keep their N-Triples escapes, language tag and
+// datatype are kept separately and are not part of the JSON output, unless a
+// JSONShape asks for them. Line is the input line number, if known, else 0.
 type Triple struct {
-	XMLName   xml.Name `json:"-" xml:"t"`
-	Subject   string   `json:"s" xml:"s"`
-	Predicate string   `json:"p" xml:"p"`
-	Object    string   `json:"o" xml:"o"`
+	XMLName    xml.Name `json:"-" xml:"t"`
+	Subject    string   `json:"s" xml:"s"`
+	Predicate  string   `json:"p" xml:"p"`
+	Object     string   `json:"o" xml:"o"`
+	ObjectKind Kind     `json:"-" xml:"-"`
+	Lang       string   `json:"-" xml:"-"`
+	Datatype   string   `json:"-" xml:"-"`
+	Line       int      `json:"-" xml:"-"`
+}
+
+// TripleReader is implemented by the streaming parsers. Next returns io.EOF
+// after the last triple.
+type TripleReader interface {
+	Next() (*Triple, error)
+}
+
+// String returns the triple as an N-Triples line, without a trailing newline.
+func (t Triple) String() string {
+	return fmt.Sprintf("%s <%s> %s .", formatSubject(t.Subject), t.Predicate, t.formatObject())
+}
+
+// formatObject returns the object in N-Triples syntax.
+func (t Triple) formatObject() string {
+	switch t.ObjectKind {
+	case Literal:
+		o := `"` + t.Object + `"`
+		if t.Lang != "" {
+			return o + "@" + t.Lang
+		} else if t.Datatype != "" {
+			return o + "^^<" + t.Datatype + ">"
+		}
+		return o
+	case BlankNode:
+		return t.Object
+	}
+	return "<" + t.Object + ">"
+}
+
+// formatSubject wraps IRIs in angle brackets and leaves blank nodes alone.
+func formatSubject(s string) string {
+	if strings.HasPrefix(s, "_:") {
+		return s
+	}
+	return "<" + s + ">"
 }
 
 type Rule struct {
@@ -37,34 +91,77 @@ func (r Rule) String() string {
 	return fmt.Sprintf("%s\t%s", r.Shortcut, r.Prefix)
 }
 
-// Simplistic NTriples parser
+// Simplistic NTriples parser. Subject and predicate are single terms. A
+// literal object is taken verbatim, including any whitespace; other objects
+// may contain spaces, which are collapsed.
 func ParseNTriple(line string) (*Triple, error) {
 	line = strings.TrimSpace(line)
-	words := strings.Fields(line)
-	if len(words) < 3 {
-		return nil, errors.New(fmt.Sprintf("broken input: %s", words))
+	var words []string
+	i := 0
+	for len(words) < 2 {
+		if i = skipSpace(line, i); i == len(line) {
+			break
+		}
+		word, next, err := scanTerm(line, i)
+		if err != nil {
+			return nil, err
+		}
+		words, i = append(words, word), next
+	}
+	rest := strings.TrimSpace(line[i:])
+	if len(words) < 2 || rest == "" || rest == "." {
+		return nil, errors.New(fmt.Sprintf("broken input: %s", strings.Fields(line)))
 	}
 	var s, p, o string
 
 	s = words[0]
 	p = words[1]
 
-	if len(words) <= 4 {
-		o = words[2]
-	} else if len(words) > 4 {
-		if strings.HasSuffix(line, ".") {
-			o = strings.Join(words[2:len(words)-1], " ")
-		} else {
-			o = strings.Join(words[2:len(words)], " ")
+	if strings.HasPrefix(rest, "\"") {
+		// an unterminated literal is split into words below, as before
+		o, _, _ = scanTerm(rest, 0)
+	}
+	if o == "" {
+		fields := strings.Fields(rest)
+		if len(fields) > 1 && fields[len(fields)-1] == "." {
+			fields = fields[:len(fields)-1]
 		}
+		o = strings.Join(fields, " ")
 	}
+
 	s = strings.Trim(s, "<>\"")
 	p = strings.Trim(p, "<>\"")
-	o = strings.Trim(o, "<>\"")
-	triple := Triple{Subject: s, Predicate: p, Object: o}
+	triple := Triple{Subject: s, Predicate: p}
+	parseObject(o, &triple)
 	return &triple, nil
 }
 
+// parseObject sets object, kind, language and datatype from the raw object
+// term. Anything that is neither a literal nor a blank node counts as IRI.
+func parseObject(o string, t *Triple) {
+	if strings.HasSuffix(o, ">.") || strings.HasSuffix(o, "\".") {
+		o = strings.TrimSuffix(o, ".")
+	}
+	switch {
+	case strings.HasPrefix(o, "_:"):
+		t.ObjectKind = BlankNode
+		t.Object = o
+	case strings.HasPrefix(o, "\"") && strings.LastIndex(o, "\"") > 0:
+		i := strings.LastIndex(o, "\"")
+		t.ObjectKind = Literal
+		t.Object = o[1:i]
+		suffix := o[i+1:]
+		switch {
+		case strings.HasPrefix(suffix, "@"):
+			t.Lang = suffix[1:]
+		case strings.HasPrefix(suffix, "^^"):
+			t.Datatype = strings.Trim(suffix[2:], "<>")
+		}
+	default:
+		t.Object = strings.Trim(o, "<>\"")
+	}
+}
+
 // ParseAbbreviations takes a string, parse the abbreviations and returns them as slice
 func ParseRules(s string) ([]Rule, error) {
 	var rules []Rule
@@ -84,17 +181,62 @@ func ParseRules(s string) ([]Rule, error) {
 	return rules, err
 }
 
-// PartitionRules divides the rules slice into `count` partitions
+// PartitionRules divides the rules slice into `count` partitions. Rules whose
+// prefixes overlap, like http://www.wikidata.org/prop/ and
+// http://www.wikidata.org/prop/direct/, end up in the same partition, in
+// their original order, so the first matching rule wins, as with a single
+// partition.
 func PartitionRules(rules []Rule, count int) [][]Rule {
-	count = int(math.Min(float64(len(rules)), float64(count)))
+	groups := overlappingRules(rules)
+	count = int(math.Min(float64(len(groups)), float64(count)))
 	partitions := make([][]Rule, count)
-	for i, rule := range rules {
+	for i, group := range groups {
 		p := i % count
-		partitions[p] = append(partitions[p], rule)
+		partitions[p] = append(partitions[p], group...)
 	}
 	return partitions
 }
 
+// overlappingRules groups rules whose prefixes are prefixes of one another,
+// in order of their first rule. Within a group, rules keep their order.
+func overlappingRules(rules []Rule) [][]Rule {
+	group := make([]int, len(rules))
+	for i := range group {
+		group[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	for i := range rules {
+		for j := i + 1; j < len(rules); j++ {
+			if strings.HasPrefix(rules[i].Prefix, rules[j].Prefix) || strings.HasPrefix(rules[j].Prefix, rules[i].Prefix) {
+				a, b := find(i), find(j)
+				if a > b {
+					a, b = b, a
+				}
+				group[b] = a
+			}
+		}
+	}
+	var groups [][]Rule
+	index := make(map[int]int)
+	for i, rule := range rules {
+		root := find(i)
+		k, ok := index[root]
+		if !ok {
+			k = len(groups)
+			index[root] = k
+			groups = append(groups, nil)
+		}
+		groups[k] = append(groups[k], rule)
+	}
+	return groups
+}
+
 // Turn rules into a sed command `in` as input, `out` as output filename
 func Sedify(rules []Rule, p int, in string) string {
 	return SedifyNull(rules, p, in, "<NULL>")
@@ -126,6 +268,16 @@ func SedifyNull(rules []Rule, p int, in, null string) string {
 	return strings.Join(replacements, " | ")
 }
 
+// SortBySubject returns a shell command that sorts `in` by subject, keeping
+// the input order of triples with the same subject. Reads stdin, if `in` is
+// empty.
+func SortBySubject(in string) string {
+	if in == "" {
+		return "LANG=C sort -s -k1,1"
+	}
+	return fmt.Sprintf("LANG=C sort -s -k1,1 < '%s'", in)
+}
+
 func Replacify(rules []Rule, in string) string {
 	return ReplacifyNull(rules, in, "<NULL>")
 }
@@ -141,3 +293,86 @@ func ReplacifyNull(rules []Rule, in, null string) string {
 	}
 	return fmt.Sprintf("replace %s < %s", buffer.String(), in)
 }
+
+// escapeLiteral escapes a lexical form the way it would appear in N-Triples.
+// Control characters without a short escape become \uXXXX.
+func escapeLiteral(s string) string {
+	if !strings.ContainsAny(s, "\\\"") && strings.IndexFunc(s, isControl) < 0 {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if isControl(r) {
+				fmt.Fprintf(&sb, "\\u%04X", r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// isControl reports whether r is an ASCII control character.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// unescapeLiteral resolves the N-Triples escapes of a lexical form. Broken
+// escapes are kept as they are.
+func unescapeLiteral(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case '"', '\'', '\\':
+			sb.WriteByte(s[i])
+		case 'u', 'U':
+			n := 4
+			if s[i] == 'U' {
+				n = 8
+			}
+			if i+n < len(s) {
+				if v, err := strconv.ParseUint(s[i+1:i+1+n], 16, 32); err == nil {
+					sb.WriteRune(rune(v))
+					i += n
+					continue
+				}
+			}
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
@@ -2,7 +2,9 @@ package ntto
 
 import (
 	"errors"
+	"os/exec"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -182,6 +184,19 @@ func TestSedify(t *testing.T) {
 	}
 }
 
+func TestSortBySubject(t *testing.T) {
+	out := SortBySubject("hello.txt")
+	want := "LANG=C sort -s -k1,1 < 'hello.txt'"
+	if out != want {
+		t.Errorf("SortBySubject(hello.txt) => %s, want: %s", out, want)
+	}
+	out = SortBySubject("")
+	want = "LANG=C sort -s -k1,1"
+	if out != want {
+		t.Errorf("SortBySubject() => %s, want: %s", out, want)
+	}
+}
+
 var ParseNTripleTests = []struct {
 	in  string
 	out Triple
@@ -193,11 +208,11 @@ var ParseNTripleTests = []struct {
 	{`a b c .`,
 		Triple{Subject: "a", Predicate: "b", Object: "c"}},
 	{`a b "the deep blue c" .`,
-		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c"}},
+		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c", ObjectKind: Literal}},
 	{`a <b> "the deep blue c" .`,
-		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c"}},
+		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c", ObjectKind: Literal}},
 	{`<a> <b> "the deep blue c" .`,
-		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c"}},
+		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c", ObjectKind: Literal}},
 	{`<a> <b> <the deep blue c> .`,
 		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c"}},
 	{`<a> <b> <the deep blue c>`,
@@ -206,6 +221,27 @@ var ParseNTripleTests = []struct {
 		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c"}},
 	{`<a>    <b>  <the         deep blue c>`,
 		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c"}},
+	{`<a> <b> "c" .`,
+		Triple{Subject: "a", Predicate: "b", Object: "c", ObjectKind: Literal}},
+	{`<a> <b> <c>.`,
+		Triple{Subject: "a", Predicate: "b", Object: "c"}},
+	{`<a> <b> "the deep blue c"@en .`,
+		Triple{Subject: "a", Predicate: "b", Object: "the deep blue c", ObjectKind: Literal, Lang: "en"}},
+	{`<a> <b> "say \"c\"" .`,
+		Triple{Subject: "a", Predicate: "b", Object: `say \"c\"`, ObjectKind: Literal}},
+	{`<a> <b> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		Triple{Subject: "a", Predicate: "b", Object: "1", ObjectKind: Literal,
+			Datatype: "http://www.w3.org/2001/XMLSchema#integer"}},
+	{`_:b0 <b> _:b1 .`,
+		Triple{Subject: "_:b0", Predicate: "b", Object: "_:b1", ObjectKind: BlankNode}},
+	{`<a> <b> "Goethe  Johann"@de .`,
+		Triple{Subject: "a", Predicate: "b", Object: "Goethe  Johann", ObjectKind: Literal, Lang: "de"}},
+	{"<a>\t<b>\t\"x \t y\" .",
+		Triple{Subject: "a", Predicate: "b", Object: "x \t y", ObjectKind: Literal}},
+	{`<a> <b> "x"@en.`,
+		Triple{Subject: "a", Predicate: "b", Object: "x", ObjectKind: Literal, Lang: "en"}},
+	{`<a> <b> "1 . 2"^^<d>.`,
+		Triple{Subject: "a", Predicate: "b", Object: "1 . 2", ObjectKind: Literal, Datatype: "d"}},
 }
 
 func TestParseNTriple(t *testing.T) {
@@ -216,3 +252,113 @@ func TestParseNTriple(t *testing.T) {
 		}
 	}
 }
+
+var TripleStringTests = []struct {
+	in  Triple
+	out string
+}{
+	{Triple{Subject: "a", Predicate: "b", Object: "c"}, `<a> <b> <c> .`},
+	{Triple{Subject: "_:a", Predicate: "b", Object: "_:c", ObjectKind: BlankNode}, `_:a <b> _:c .`},
+	{Triple{Subject: "a", Predicate: "b", Object: "c", ObjectKind: Literal}, `<a> <b> "c" .`},
+	{Triple{Subject: "a", Predicate: "b", Object: "c", ObjectKind: Literal, Lang: "de"}, `<a> <b> "c"@de .`},
+	{Triple{Subject: "a", Predicate: "b", Object: "c", ObjectKind: Literal, Datatype: "d"}, `<a> <b> "c"^^<d> .`},
+}
+
+func TestTripleString(t *testing.T) {
+	for _, tt := range TripleStringTests {
+		out := tt.in.String()
+		if out != tt.out {
+			t.Errorf("Triple.String(%+v) => %s, want: %s", tt.in, out, tt.out)
+		}
+	}
+}
+
+var UnescapeLiteralTests = []struct {
+	in  string
+	out string
+}{
+	{`plain`, "plain"},
+	{`say \"hi\"`, `say "hi"`},
+	{`a\nb\tc\\d`, "a\nb\tc\\d"},
+	{`ä\U0001F600`, "ä😀"},
+	{`broken \u00`, `broken \u00`},
+	{`trailing \`, `trailing \`},
+}
+
+func TestUnescapeLiteral(t *testing.T) {
+	for _, tt := range UnescapeLiteralTests {
+		out := unescapeLiteral(tt.in)
+		if out != tt.out {
+			t.Errorf("unescapeLiteral(%s) => %q, want: %q", tt.in, out, tt.out)
+		}
+	}
+}
+
+var EscapeLiteralTests = []struct {
+	in  string
+	out string
+}{
+	{"plain", `plain`},
+	{"say \"hi\"\n", `say \"hi\"\n`},
+	{"a\x00b\x1fc\x7f\b\f", `a\u0000b\u001Fc\u007F\u0008\u000C`},
+	{"ä\t\\", `ä\t\\`},
+}
+
+func TestEscapeLiteral(t *testing.T) {
+	for _, tt := range EscapeLiteralTests {
+		out := escapeLiteral(tt.in)
+		if out != tt.out {
+			t.Errorf("escapeLiteral(%q) => %s, want: %s", tt.in, out, tt.out)
+		}
+		if back := unescapeLiteral(out); back != tt.in {
+			t.Errorf("unescapeLiteral(%s) => %q, want: %q", out, back, tt.in)
+		}
+	}
+}
+
+func TestSedifyOverlappingPrefixes(t *testing.T) {
+	if _, err := exec.LookPath("perl"); err != nil {
+		t.Skip("perl not found")
+	}
+	rules, ok := BuiltinProfile("wikidata")
+	if !ok {
+		t.Fatal("no wikidata profile")
+	}
+	in := strings.Join([]string{
+		"<http://www.wikidata.org/entity/Q42> <http://www.wikidata.org/prop/direct/P31> <http://www.wikidata.org/entity/Q5> .",
+		"<http://www.wikidata.org/entity/statement/Q42-1> <http://www.wikidata.org/prop/statement/value/P569> <http://www.wikidata.org/value/abc> .",
+		"<http://www.wikidata.org/entity/Q42> <http://www.wikidata.org/prop/P31> <http://www.wikidata.org/entity/statement/Q42-1> .",
+	}, "\n")
+	want := strings.Join([]string{
+		"<wd:Q42> <wdt:P31> <wd:Q5> .",
+		"<wds:Q42-1> <psv:P569> <wdv:abc> .",
+		"<wd:Q42> <p:P31> <wds:Q42-1> .",
+	}, "\n") + "\n"
+	for _, p := range []int{1, 2, 4, 16} {
+		cmd := exec.Command("sh", "-c", Sedify(rules, p, ""))
+		cmd.Stdin = strings.NewReader(in + "\n")
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != want {
+			t.Errorf("%d partitions: got %s, want %s", p, out, want)
+		}
+	}
+}
+
+func TestPartitionRulesOverlapping(t *testing.T) {
+	rules := []Rule{
+		{Shortcut: "p", Prefix: "http://x.org/prop/"},
+		{Shortcut: "a", Prefix: "http://a.org/"},
+		{Shortcut: "pd", Prefix: "http://x.org/prop/direct/"},
+		{Shortcut: "b", Prefix: "http://b.org/"},
+	}
+	want := [][]Rule{
+		{rules[0], rules[2], rules[3]},
+		{rules[1]},
+	}
+	if out := PartitionRules(rules, 2); !reflect.DeepEqual(out, want) {
+		t.Errorf("PartitionRules => %+v, want: %+v", out, want)
+	}
+}
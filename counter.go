@@ -0,0 +1,100 @@
+package ntto
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Count is a key with its count. Err is an upper bound on how much the count
+// might be too high.
+type Count struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+	Err   int64  `json:"err,omitempty"`
+}
+
+type counterEntry struct {
+	Count
+	index int
+}
+
+// counterHeap is a min-heap of entries by count.
+type counterHeap []*counterEntry
+
+func (h counterHeap) Len() int           { return len(h) }
+func (h counterHeap) Less(i, j int) bool { return h[i].Count.Count < h[j].Count.Count }
+func (h counterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *counterHeap) Push(x interface{}) {
+	e := x.(*counterEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *counterHeap) Pop() interface{} {
+	old := *h
+	e := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return e
+}
+
+// Counter counts keys in bounded memory. It keeps at most max keys exactly;
+// once full, a new key replaces the least frequent key and inherits its count
+// (the space-saving algorithm), so frequent keys stay and their counts are
+// off by at most Err.
+type Counter struct {
+	max     int
+	entries map[string]*counterEntry
+	heap    counterHeap
+}
+
+// NewCounter returns a counter keeping at most max keys.
+func NewCounter(max int) *Counter {
+	return &Counter{max: max, entries: make(map[string]*counterEntry)}
+}
+
+// Add adds n to the count of key.
+func (c *Counter) Add(key string, n int64) {
+	if e, ok := c.entries[key]; ok {
+		e.Count.Count += n
+		heap.Fix(&c.heap, e.index)
+		return
+	}
+	if len(c.heap) < c.max {
+		e := &counterEntry{Count: Count{Key: key, Count: n}}
+		c.entries[key] = e
+		heap.Push(&c.heap, e)
+		return
+	}
+	e := c.heap[0]
+	delete(c.entries, e.Key)
+	e.Key, e.Err = key, e.Count.Count
+	e.Count.Count += n
+	c.entries[key] = e
+	heap.Fix(&c.heap, 0)
+}
+
+// Top returns the n most frequent keys, all keys if n is zero.
+func (c *Counter) Top(n int) []Count {
+	counts := make([]Count, 0, len(c.heap))
+	for _, e := range c.heap {
+		counts = append(counts, e.Count)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count == counts[j].Count {
+			return counts[i].Key < counts[j].Key
+		}
+		return counts[i].Count > counts[j].Count
+	})
+	if n > 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+// Len returns the number of keys kept.
+func (c *Counter) Len() int {
+	return len(c.heap)
+}
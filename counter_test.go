@@ -0,0 +1,34 @@
+package ntto
+
+import (
+	"reflect"
+	"testing"
+)
+
+var CounterTests = []struct {
+	max  int
+	keys []string
+	top  int
+	out  []Count
+}{
+	{10, nil, 0, []Count{}},
+	{10, []string{"a", "b", "a", "c", "a", "b"}, 0,
+		[]Count{{Key: "a", Count: 3}, {Key: "b", Count: 2}, {Key: "c", Count: 1}}},
+	{10, []string{"a", "b", "a", "c", "a", "b"}, 2,
+		[]Count{{Key: "a", Count: 3}, {Key: "b", Count: 2}}},
+	{2, []string{"a", "a", "a", "b", "c"}, 0,
+		[]Count{{Key: "a", Count: 3}, {Key: "c", Count: 2, Err: 1}}},
+}
+
+func TestCounter(t *testing.T) {
+	for _, tt := range CounterTests {
+		c := NewCounter(tt.max)
+		for _, key := range tt.keys {
+			c.Add(key, 1)
+		}
+		out := c.Top(tt.top)
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("Counter(%d, %v).Top(%d) => %+v, want: %+v", tt.max, tt.keys, tt.top, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package ntto
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Differ compares two N-Triples or N-Quads streams that need not fit into
+// memory. Both streams are sorted with an external sort first.
+type Differ struct {
+	// Sorter configures memory limit, temporary directory and error
+	// handling. Order and Unique are ignored.
+	Sorter Sorter
+	// NormalizeBlankNodes relabels blank nodes by their surroundings, so
+	// unchanged blank nodes do not show up as changes. Unlike the sort, this
+	// keeps a label and a hash for every blank node in memory.
+	NormalizeBlankNodes bool
+}
+
+// Diff calls fn for each quad only in old (added is false) and each quad only
+// in new (added is true), in SPO order.
+func (d *Differ) Diff(old, new io.Reader, fn func(added bool, q *Quad) error) error {
+	a, err := d.prepare(old)
+	if a != "" {
+		defer os.Remove(a)
+	}
+	if err != nil {
+		return err
+	}
+	b, err := d.prepare(new)
+	if b != "" {
+		defer os.Remove(b)
+	}
+	if err != nil {
+		return err
+	}
+	fa, err := os.Open(a)
+	if err != nil {
+		return err
+	}
+	defer fa.Close()
+	fb, err := os.Open(b)
+	if err != nil {
+		return err
+	}
+	defer fb.Close()
+	return DiffSorted(fa, fb, fn)
+}
+
+// sortToFile sorts r into a temporary file, unique and in SPO order.
+func (d *Differ) sortToFile(r io.Reader) (string, error) {
+	s := d.Sorter
+	s.Order, s.Unique = SPO, true
+	f, err := ioutil.TempFile(s.TempDir, "ntto-diff-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := s.Sort(r, f); err != nil {
+		return f.Name(), err
+	}
+	return f.Name(), f.Close()
+}
+
+// prepare sorts a stream and normalizes its blank nodes, if requested. The
+// name of the resulting temporary file is returned, even on errors.
+func (d *Differ) prepare(r io.Reader) (string, error) {
+	sorted, err := d.sortToFile(r)
+	if err != nil || !d.NormalizeBlankNodes {
+		return sorted, err
+	}
+	f, err := os.Open(sorted)
+	if err != nil {
+		return sorted, err
+	}
+	defer f.Close()
+	hasher := NewBlankNodeHasher()
+	if err := eachQuad(f, func(q *Quad) error {
+		hasher.Add(q)
+		return nil
+	}); err != nil {
+		return sorted, err
+	}
+	labels := hasher.Labels()
+	if len(labels) == 0 {
+		return sorted, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return sorted, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		bw := bufio.NewWriter(pw)
+		err := eachQuad(f, func(q *Quad) error {
+			_, err := bw.WriteString(RelabelBlankNodes(q, labels).String() + "\n")
+			return err
+		})
+		if err == nil {
+			err = bw.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	relabeled, err := d.sortToFile(pr)
+	pr.Close()
+	os.Remove(sorted)
+	return relabeled, err
+}
+
+// eachQuad calls fn for every line of r, which must be valid N-Quads.
+func eachQuad(r io.Reader, fn func(*Quad) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			q, perr := ParseNQuad(line)
+			if perr != nil {
+				return perr
+			}
+			if ferr := fn(q); ferr != nil {
+				return ferr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// quadStream reads quads one at a time.
+type quadStream struct {
+	r   *bufio.Reader
+	q   *Quad
+	key string
+}
+
+func (s *quadStream) next() error {
+	for {
+		line, err := s.r.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			q, perr := ParseNQuad(line)
+			if perr != nil {
+				return perr
+			}
+			s.q, s.key = q, SPO.key(q)
+			return nil
+		}
+		if err != nil {
+			s.q = nil
+			return err
+		}
+	}
+}
+
+// DiffSorted compares two streams of N-Quads, each sorted in SPO order and
+// without duplicates, as written by a unique Sorter.
+func DiffSorted(old, new io.Reader, fn func(added bool, q *Quad) error) error {
+	a := &quadStream{r: bufio.NewReader(old)}
+	b := &quadStream{r: bufio.NewReader(new)}
+	for _, s := range []*quadStream{a, b} {
+		if err := s.next(); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	for a.q != nil || b.q != nil {
+		var err error
+		switch {
+		case b.q == nil || (a.q != nil && a.key < b.key):
+			if err = fn(false, a.q); err == nil {
+				err = a.next()
+			}
+		case a.q == nil || b.key < a.key:
+			if err = fn(true, b.q); err == nil {
+				err = b.next()
+			}
+		default:
+			if err = a.next(); err == nil || err == io.EOF {
+				err = b.next()
+			}
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package ntto
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func diffLines(t *testing.T, d *Differ, old, new string) []string {
+	var out []string
+	err := d.Diff(strings.NewReader(old), strings.NewReader(new), func(added bool, q *Quad) error {
+		op := "D "
+		if added {
+			op = "A "
+		}
+		out = append(out, op+q.String())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	old := "<a> <p> <b> .\n<a> <p> <c> .\n<c> <p> \"x\" .\n<a> <p> <b> .\n"
+	new := "<c> <p> \"x\" .\n<a> <p> <b> .\n<d> <p> <e> <g> .\n"
+	out := diffLines(t, &Differ{Sorter: Sorter{MemoryLimit: 1}}, old, new)
+	want := []string{"D <a> <p> <c> .", "A <d> <p> <e> <g> ."}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Diff => %q, want: %q", out, want)
+	}
+}
+
+func TestDiffBlankNodes(t *testing.T) {
+	old := "<a> <p> _:x .\n_:x <name> \"n\" .\n<c> <p> \"1\" .\n"
+	new := "<a> <p> _:b0 .\n_:b0 <name> \"n\" .\n<c> <p> \"2\" .\n"
+	// without normalization every triple with a blank node changes
+	if out := diffLines(t, &Differ{}, old, new); len(out) != 6 {
+		t.Errorf("Diff => %q, want 6 changes", out)
+	}
+	out := diffLines(t, &Differ{NormalizeBlankNodes: true}, old, new)
+	want := []string{`D <c> <p> "1" .`, `A <c> <p> "2" .`}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("Diff => %q, want: %q", out, want)
+	}
+}
+
+func TestDiffKeepsLiterals(t *testing.T) {
+	old := "<a> <p> \"x   y\\tz\" .\n<a> <p> \"tail _:b1\" .\n<a> <p> \"q\\\" <c> _:x\" <g> .\n"
+	new := "<a> <p> \"x y z\" .\n<a> <p> \"tail _:b1\" .\n<a> <p> \"q\\\" <c> _:x\" <g> .\n"
+	for _, bnodes := range []bool{false, true} {
+		out := diffLines(t, &Differ{NormalizeBlankNodes: bnodes}, old, new)
+		want := []string{`D <a> <p> "x   y\tz" .`, `A <a> <p> "x y z" .`}
+		if !reflect.DeepEqual(out, want) {
+			t.Errorf("Diff (bnodes %v) => %q, want: %q", bnodes, out, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package ntto
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Encoder writes triples in some output format. Encoders may buffer, so Flush
+// must be called after the last triple.
+type Encoder interface {
+	Encode(t *Triple) error
+	Flush() error
+}
+
+// JSONEncoder writes one JSON object per triple and line.
+type JSONEncoder struct {
+	w   io.Writer
+	buf []byte
+	// Typed, if set, writes typed literals as native JSON values.
+	Typed *TypedLiterals
+	// Shape, if set, is the layout of the documents, instead of s, p and o.
+	Shape *JSONShape
+}
+
+// NewJSONEncoder returns an encoder writing line delimited JSON to w.
+func NewJSONEncoder(w io.Writer) *JSONEncoder {
+	return &JSONEncoder{w: w}
+}
+
+// Encode writes a single triple.
+func (e *JSONEncoder) Encode(t *Triple) error {
+	if e.Shape != nil {
+		return e.EncodeQuad(&Quad{Triple: *t})
+	}
+	if e.Typed == nil {
+		e.buf = append(t.AppendJSON(e.buf[:0]), '\n')
+		_, err := e.w.Write(e.buf)
+		return err
+	}
+	b, err := marshalTriple(t, e.Typed)
+	if err != nil {
+		return err
+	}
+	return e.writeLine(b)
+}
+
+// EncodeQuad writes a single quad, with the graph as g, unless the shape
+// says otherwise.
+func (e *JSONEncoder) EncodeQuad(q *Quad) error {
+	shape := e.Shape
+	if shape == nil {
+		shape = quadShape
+	}
+	b, err := shape.Marshal(q, e.Typed)
+	if err != nil {
+		return err
+	}
+	return e.writeLine(b)
+}
+
+// writeLine writes b and a newline in a single call, so a SplitWriter never
+// separates them.
+func (e *JSONEncoder) writeLine(b []byte) error {
+	e.buf = append(append(e.buf[:0], b...), '\n')
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// typedTriple is the JSON form of a triple with a typed object.
+type typedTriple struct {
+	Subject   string      `json:"s"`
+	Predicate string      `json:"p"`
+	Object    interface{} `json:"o"`
+}
+
+// marshalTriple returns the JSON object of a triple, with a typed object,
+// if typed is not nil.
+func marshalTriple(t *Triple, typed *TypedLiterals) ([]byte, error) {
+	if typed == nil {
+		return t.AppendJSON(nil), nil
+	}
+	return json.Marshal(typedTriple{Subject: t.Subject, Predicate: t.Predicate, Object: typed.Value(t)})
+}
+
+// Flush is a no-op, JSONEncoder does not buffer.
+func (e *JSONEncoder) Flush() error {
+	return nil
+}
+
+// NTriplesEncoder writes triples as N-Triples.
+type NTriplesEncoder struct {
+	w io.Writer
+}
+
+// NewNTriplesEncoder returns an encoder writing N-Triples to w.
+func NewNTriplesEncoder(w io.Writer) *NTriplesEncoder {
+	return &NTriplesEncoder{w: w}
+}
+
+// Encode writes a single triple.
+func (e *NTriplesEncoder) Encode(t *Triple) error {
+	_, err := io.WriteString(e.w, t.String()+"\n")
+	return err
+}
+
+// Flush is a no-op, NTriplesEncoder does not buffer.
+func (e *NTriplesEncoder) Flush() error {
+	return nil
+}
+
+// EncoderOptions configure the encoders returned by NewEncoder.
+type EncoderOptions struct {
+	// Rules are used for JSON-LD contexts.
+	Rules []Rule
+	// IndexName is the index of esbulk output.
+	IndexName string
+	// Grouped merges consecutive triples of a subject into one document, for
+	// json and esbulk output.
+	Grouped bool
+	// Typed, if set, writes typed literals as native JSON values, for json
+	// and esbulk output.
+	Typed *TypedLiterals
+	// Shape, if set, is the layout of ungrouped json output.
+	Shape *JSONShape
+}
+
+// NewEncoder returns an encoder for the given output format.
+func NewEncoder(format string, w io.Writer, opts EncoderOptions) (Encoder, error) {
+	if opts.Shape != nil && (format != "json" || opts.Grouped) {
+		return nil, fmt.Errorf("shapes only apply to ungrouped json output")
+	}
+	switch format {
+	case "nt":
+		return NewNTriplesEncoder(w), nil
+	case "json":
+		if opts.Grouped {
+			e := NewSubjectEncoder(w)
+			e.Typed = opts.Typed
+			return e, nil
+		}
+		e := NewJSONEncoder(w)
+		e.Typed = opts.Typed
+		e.Shape = opts.Shape
+		return e, nil
+	case "esbulk":
+		e := NewBulkEncoder(w, opts.IndexName, opts.Grouped)
+		e.Typed = opts.Typed
+		return e, nil
+	case "msgpack":
+		return NewMessagePackEncoder(w), nil
+	case "cbor":
+		return NewCBOREncoder(w), nil
+	case "jsonld":
+		return NewJSONLDEncoder(w, opts.Rules, true), nil
+	case "jsonld-expanded":
+		return NewJSONLDEncoder(w, opts.Rules, false), nil
+	}
+	return nil, fmt.Errorf("unknown output format: %s", format)
+}
@@ -0,0 +1,134 @@
+package ntto
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// BulkEncoder writes Elasticsearch (or OpenSearch) bulk NDJSON, alternating
+// an index action and a document. Documents are single triples or, if
+// grouped, all consecutive triples of a subject. The document id is derived
+// from the subject; per triple, a hash of the triple is appended, so triples
+// of one subject do not overwrite each other.
+type BulkEncoder struct {
+	w        io.Writer
+	index    string
+	subjects *SubjectEncoder
+	buf      bytes.Buffer
+	// Typed, if set, writes typed literals as native JSON values.
+	Typed *TypedLiterals
+}
+
+// NewBulkEncoder returns an encoder writing bulk requests for index to w.
+func NewBulkEncoder(w io.Writer, index string, grouped bool) *BulkEncoder {
+	e := &BulkEncoder{w: w, index: index}
+	if grouped {
+		e.subjects = NewSubjectEncoder(w)
+		e.subjects.emit = e.write
+	}
+	return e
+}
+
+type bulkAction struct {
+	Index struct {
+		Index string `json:"_index"`
+		ID    string `json:"_id"`
+	} `json:"index"`
+}
+
+// write writes action and document in a single call, so a SplitWriter never
+// separates them.
+func (e *BulkEncoder) write(id string, doc []byte) error {
+	var action bulkAction
+	action.Index.Index = e.index
+	action.Index.ID = id
+	b, err := json.Marshal(action)
+	if err != nil {
+		return err
+	}
+	e.buf.Reset()
+	e.buf.Write(b)
+	e.buf.WriteString("\n")
+	e.buf.Write(doc)
+	if len(doc) == 0 || doc[len(doc)-1] != '\n' {
+		e.buf.WriteString("\n")
+	}
+	_, err = e.w.Write(e.buf.Bytes())
+	return err
+}
+
+// Encode writes a triple or adds it to the current subject document.
+func (e *BulkEncoder) Encode(t *Triple) error {
+	if e.subjects != nil {
+		e.subjects.Typed = e.Typed
+		return e.subjects.Encode(t)
+	}
+	doc, err := marshalTriple(t, e.Typed)
+	if err != nil {
+		return err
+	}
+	id := fmt.Sprintf("%s-%x", t.Subject, sha1.Sum([]byte(t.String())))
+	return e.write(id, doc)
+}
+
+// Flush writes the last subject document, if grouped.
+func (e *BulkEncoder) Flush() error {
+	if e.subjects != nil {
+		return e.subjects.Flush()
+	}
+	return nil
+}
+
+// SplitWriter writes into a sequence of files named after a pattern like
+// "bulk-%05d.ndjson". It starts a new file before a write would grow the
+// current file beyond limit bytes. A single write is never split.
+type SplitWriter struct {
+	pattern string
+	limit   int64
+	file    *os.File
+	buf     *bufio.Writer
+	size    int64
+	count   int
+}
+
+// NewSplitWriter returns a SplitWriter, files are created on first write.
+func NewSplitWriter(pattern string, limit int64) *SplitWriter {
+	return &SplitWriter{pattern: pattern, limit: limit}
+}
+
+// Write writes p to the current file, rotating first if needed.
+func (w *SplitWriter) Write(p []byte) (int, error) {
+	if w.file == nil || (w.size > 0 && w.size+int64(len(p)) > w.limit) {
+		if err := w.Close(); err != nil {
+			return 0, err
+		}
+		file, err := os.Create(fmt.Sprintf(w.pattern, w.count))
+		if err != nil {
+			return 0, err
+		}
+		w.file, w.size = file, 0
+		w.buf = bufio.NewWriter(file)
+		w.count++
+	}
+	n, err := w.buf.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file.
+func (w *SplitWriter) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
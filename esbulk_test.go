@@ -0,0 +1,132 @@
+package ntto
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var BulkEncoderTests = []struct {
+	triples []Triple
+	grouped bool
+	out     string
+}{
+	{[]Triple{
+		Triple{Subject: "a", Predicate: "p", Object: "1"},
+	}, false, `{"index":{"_index":"idx","_id":"a-5ba1d67ec4d853a852a780b28c56d83834048e27"}}
+{"s":"a","p":"p","o":"1"}
+`},
+	{[]Triple{
+		Triple{Subject: "a", Predicate: "p", Object: "1"},
+		Triple{Subject: "a", Predicate: "p", Object: "2"},
+		Triple{Subject: "b", Predicate: "q", Object: "3"},
+	}, true, `{"index":{"_index":"idx","_id":"a"}}
+{"@id":"a","p":["1","2"]}
+{"index":{"_index":"idx","_id":"b"}}
+{"@id":"b","q":["3"]}
+`},
+}
+
+func TestBulkEncoder(t *testing.T) {
+	for _, tt := range BulkEncoderTests {
+		var buf bytes.Buffer
+		enc := NewBulkEncoder(&buf, "idx", tt.grouped)
+		for i := range tt.triples {
+			if err := enc.Encode(&tt.triples[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("BulkEncoder(%v, grouped=%v) => %s, want: %s", tt.triples, tt.grouped, buf.String(), tt.out)
+		}
+	}
+}
+
+func TestSplitWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ntto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w := NewSplitWriter(filepath.Join(dir, "part-%d"), 8)
+	for _, s := range []string{"aaaa", "bbbb", "cc", "dddddddddd", "e"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for i := 0; i < 4; i++ {
+		b, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("part-%d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(b))
+	}
+	want := []string{"aaaabbbb", "cc", "dddddddddd", "e"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("SplitWriter => %q, want: %q", out, want)
+	}
+}
+
+func TestSplitWriterDocumentBoundary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ntto-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	shape, err := ParseJSONShape("array")
+	if err != nil {
+		t.Fatal(err)
+	}
+	triple := &Triple{Subject: "a", Predicate: "p", Object: "1", ObjectKind: Literal,
+		Datatype: "http://www.w3.org/2001/XMLSchema#integer"}
+	var tests = []struct {
+		name string
+		opts EncoderOptions
+		doc  string
+	}{
+		{"typed", EncoderOptions{Typed: NewTypedLiterals(nil)}, `{"s":"a","p":"p","o":1}` + "\n"},
+		{"shape", EncoderOptions{Shape: shape}, `["a","p","1"]` + "\n"},
+	}
+	for _, tt := range tests {
+		// the limit falls right after the first document, before its newline
+		pattern := filepath.Join(dir, tt.name+"-%d")
+		w := NewSplitWriter(pattern, int64(len(tt.doc)-1))
+		e, err := NewEncoder("json", w, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := e.Encode(triple); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			b, err := ioutil.ReadFile(fmt.Sprintf(pattern, i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.doc {
+				t.Errorf("%s: file %d: got %q, want %q", tt.name, i, b, tt.doc)
+			}
+		}
+	}
+}
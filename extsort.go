@@ -0,0 +1,276 @@
+package ntto
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Order is the component order used to sort triples and quads. The graph is
+// always compared last.
+type Order int
+
+const (
+	SPO Order = iota
+	POS
+	OSP
+)
+
+// ParseOrder parses spo, pos or osp, in any case.
+func ParseOrder(s string) (Order, error) {
+	switch strings.ToLower(s) {
+	case "spo":
+		return SPO, nil
+	case "pos":
+		return POS, nil
+	case "osp":
+		return OSP, nil
+	}
+	return SPO, fmt.Errorf("unknown sort order: %s", s)
+}
+
+func (o Order) String() string {
+	switch o {
+	case POS:
+		return "pos"
+	case OSP:
+		return "osp"
+	}
+	return "spo"
+}
+
+// key returns a sort key for a quad. Terms are compared in their N-Quads
+// form, byte by byte, so the result does not depend on the locale. Equal keys
+// mean equal quads.
+func (o Order) key(q *Quad) string {
+	s, p, obj := formatSubject(q.Subject), "<"+q.Predicate+">", q.formatObject()
+	var g string
+	if q.Graph != "" {
+		g = formatSubject(q.Graph)
+	}
+	switch o {
+	case POS:
+		return p + "\x00" + obj + "\x00" + s + "\x00" + g
+	case OSP:
+		return obj + "\x00" + s + "\x00" + p + "\x00" + g
+	}
+	return s + "\x00" + p + "\x00" + obj + "\x00" + g
+}
+
+// DefaultMemoryLimit is used, if a Sorter has no memory limit set.
+const DefaultMemoryLimit = 256 << 20
+
+// Sorter sorts N-Triples or N-Quads that do not fit into memory. Lines are
+// parsed, sorted in runs of about MemoryLimit bytes, spilled to temporary
+// files and merged. Output lines are normalized, so lines that differ only in
+// whitespace count as duplicates.
+type Sorter struct {
+	Order       Order
+	Unique      bool
+	MemoryLimit int64
+	// TempDir is the directory for runs, the system default if empty.
+	TempDir string
+	// Ignore skips lines that cannot be parsed, instead of failing.
+	Ignore bool
+}
+
+// sortRecord is a parsed line with its sort key.
+type sortRecord struct {
+	key, line string
+}
+
+// Sort reads lines from r and writes them sorted to w.
+func (s *Sorter) Sort(r io.Reader, w io.Writer) error {
+	limit := s.MemoryLimit
+	if limit <= 0 {
+		limit = DefaultMemoryLimit
+	}
+	var (
+		records []sortRecord
+		size    int64
+		runs    []string
+	)
+	defer func() {
+		for _, run := range runs {
+			os.Remove(run)
+		}
+	}()
+
+	reader := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			q, perr := ParseNQuad(trimmed)
+			if perr != nil && !s.Ignore {
+				return fmt.Errorf("line %d: %s", n, perr)
+			}
+			if perr == nil {
+				record := sortRecord{key: s.Order.key(q), line: q.String()}
+				records = append(records, record)
+				size += int64(len(record.key) + len(record.line) + 64)
+			}
+		}
+		if size >= limit {
+			run, err := s.spill(records)
+			if err != nil {
+				return err
+			}
+			runs = append(runs, run)
+			records, size = records[:0], 0
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if len(runs) == 0 {
+		s.sortRecords(records)
+		var last string
+		for i, record := range records {
+			if s.Unique && i > 0 && record.key == last {
+				continue
+			}
+			last = record.key
+			if _, err := bw.WriteString(record.line + "\n"); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+	if len(records) > 0 {
+		run, err := s.spill(records)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+	}
+	if err := s.merge(runs, bw); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func (s *Sorter) sortRecords(records []sortRecord) {
+	sort.Slice(records, func(i, j int) bool { return records[i].key < records[j].key })
+}
+
+// spill writes sorted records to a temporary file, as alternating key and
+// line. Neither can contain a newline.
+func (s *Sorter) spill(records []sortRecord) (string, error) {
+	s.sortRecords(records)
+	f, err := ioutil.TempFile(s.TempDir, "ntto-sort-")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	bw := bufio.NewWriter(f)
+	var last string
+	for i, record := range records {
+		if s.Unique && i > 0 && record.key == last {
+			continue
+		}
+		last = record.key
+		bw.WriteString(record.key)
+		bw.WriteByte('\n')
+		bw.WriteString(record.line)
+		bw.WriteByte('\n')
+	}
+	if err := bw.Flush(); err != nil {
+		return f.Name(), err
+	}
+	return f.Name(), f.Close()
+}
+
+// run is an open spill file during the merge.
+type run struct {
+	r      *bufio.Reader
+	f      *os.File
+	record sortRecord
+}
+
+// next reads the next record, it returns io.EOF at the end of the run.
+func (r *run) next() error {
+	key, err := r.r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line, err := r.r.ReadString('\n')
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	r.record = sortRecord{key: key[:len(key)-1], line: line[:len(line)-1]}
+	return nil
+}
+
+// runHeap is a min-heap of runs by their current key.
+type runHeap []*run
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return h[i].record.key < h[j].record.key }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*run)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// merge writes the records of all runs in order.
+func (s *Sorter) merge(names []string, w *bufio.Writer) error {
+	var h runHeap
+	defer func() {
+		for _, r := range h {
+			r.f.Close()
+		}
+	}()
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		r := &run{r: bufio.NewReader(f), f: f}
+		if err := r.next(); err == io.EOF {
+			f.Close()
+			continue
+		} else if err != nil {
+			f.Close()
+			return err
+		}
+		h = append(h, r)
+	}
+	heap.Init(&h)
+	var last string
+	first := true
+	for h.Len() > 0 {
+		r := h[0]
+		if !s.Unique || first || r.record.key != last {
+			if _, err := w.WriteString(r.record.line + "\n"); err != nil {
+				return err
+			}
+			last, first = r.record.key, false
+		}
+		switch err := r.next(); err {
+		case nil:
+			heap.Fix(&h, 0)
+		case io.EOF:
+			r.f.Close()
+			heap.Pop(&h)
+		default:
+			return err
+		}
+	}
+	return nil
+}
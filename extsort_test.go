@@ -0,0 +1,96 @@
+package ntto
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var sortInput = strings.Join([]string{
+	`<b> <p> "1" .`,
+	`<a> <q> <b> .`,
+	`# comment`,
+	`<a> <p> <c> <g> .`,
+	``,
+	`<a>  <q>   <b> .`,
+	`_:x <p> <a> .`,
+}, "\n")
+
+var SorterTests = []struct {
+	order  Order
+	unique bool
+	out    []string
+}{
+	{SPO, false, []string{`<a> <p> <c> <g> .`, `<a> <q> <b> .`, `<a> <q> <b> .`, `<b> <p> "1" .`, `_:x <p> <a> .`}},
+	{SPO, true, []string{`<a> <p> <c> <g> .`, `<a> <q> <b> .`, `<b> <p> "1" .`, `_:x <p> <a> .`}},
+	{POS, true, []string{`<b> <p> "1" .`, `_:x <p> <a> .`, `<a> <p> <c> <g> .`, `<a> <q> <b> .`}},
+	{OSP, true, []string{`<b> <p> "1" .`, `_:x <p> <a> .`, `<a> <q> <b> .`, `<a> <p> <c> <g> .`}},
+}
+
+func TestSorter(t *testing.T) {
+	for _, tt := range SorterTests {
+		// a tiny memory limit forces a run per line
+		for _, limit := range []int64{0, 1} {
+			s := &Sorter{Order: tt.order, Unique: tt.unique, MemoryLimit: limit}
+			var buf bytes.Buffer
+			if err := s.Sort(strings.NewReader(sortInput), &buf); err != nil {
+				t.Fatal(err)
+			}
+			out := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if !reflect.DeepEqual(out, tt.out) {
+				t.Errorf("Sort(%s, unique=%v, limit=%d) => %q, want: %q", tt.order, tt.unique, limit, out, tt.out)
+			}
+		}
+	}
+}
+
+func TestSorterLarge(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("<http://x/%d> <http://p> \"%d\" .", rand.Intn(500), i%7))
+	}
+	want := append([]string(nil), lines...)
+	sort.Strings(want)
+	var unique []string
+	for i, line := range want {
+		if i == 0 || line != want[i-1] {
+			unique = append(unique, line)
+		}
+	}
+	s := &Sorter{Unique: true, MemoryLimit: 4096}
+	var buf bytes.Buffer
+	if err := s.Sort(strings.NewReader(strings.Join(lines, "\n")), &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if !reflect.DeepEqual(out, unique) {
+		t.Errorf("Sort => %d lines, want: %d", len(out), len(unique))
+	}
+}
+
+func TestSorterError(t *testing.T) {
+	in := "<a> <b> <c> .\nbroken\n"
+	if err := (&Sorter{}).Sort(strings.NewReader(in), &bytes.Buffer{}); err == nil || err.Error() != "line 2: broken input: [broken]" {
+		t.Errorf("Sort => %v, want: line 2 error", err)
+	}
+	var buf bytes.Buffer
+	if err := (&Sorter{Ignore: true}).Sort(strings.NewReader(in), &buf); err != nil || buf.String() != "<a> <b> <c> .\n" {
+		t.Errorf("Sort(ignore) => %q, %v", buf.String(), err)
+	}
+}
+
+func TestSorterKeepsLiterals(t *testing.T) {
+	in := "<a> <b> \"x   y\tz\" .\n<a> <b> \"x y z\" .\n<a> <b> \"tail _:b1\" .\n"
+	var buf bytes.Buffer
+	if err := (&Sorter{Unique: true}).Sort(strings.NewReader(in), &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "<a> <b> \"tail _:b1\" .\n<a> <b> \"x   y\tz\" .\n<a> <b> \"x y z\" .\n"
+	if buf.String() != want {
+		t.Errorf("Sort => %q, want: %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,75 @@
+package ntto
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern matches a single term of a triple. A pattern is written as
+//
+//	/regexp/     regular expression, matched anywhere in the term
+//	value*       prefix match
+//	<iri>        exact match, angle brackets are optional
+//	gnd:123      CURIE, matches both the abbreviated and the full form,
+//	             if a rule has the shortcut
+//
+// A CURIE may end in * as well.
+type Pattern struct {
+	values []string
+	prefix bool
+	re     *regexp.Regexp
+}
+
+// ParsePattern parses a pattern, using rules to resolve CURIEs.
+func ParsePattern(s string, rules []Rule) (*Pattern, error) {
+	if len(s) > 1 && strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
+		re, err := regexp.Compile(s[1 : len(s)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %s", s, err)
+		}
+		return &Pattern{re: re}, nil
+	}
+	p := &Pattern{}
+	if strings.HasSuffix(s, "*") {
+		p.prefix = true
+		s = strings.TrimSuffix(s, "*")
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "<"), ">")
+	p.values = []string{s}
+	if expanded := newPrefixIndex(curieRules(rules)).expand(s); expanded != s {
+		p.values = append(p.values, expanded)
+	}
+	return p, nil
+}
+
+// Match reports whether the term matches the pattern.
+func (p *Pattern) Match(s string) bool {
+	if p.re != nil {
+		return p.re.MatchString(s)
+	}
+	for _, v := range p.values {
+		if (p.prefix && strings.HasPrefix(s, v)) || s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter keeps triples whose terms match all of the given patterns. A nil
+// pattern matches anything.
+type Filter struct {
+	Subject   *Pattern
+	Predicate *Pattern
+	Object    *Pattern
+	// Invert keeps the triples that do not match instead.
+	Invert bool
+}
+
+// Match reports whether the filter keeps the triple.
+func (f *Filter) Match(t *Triple) bool {
+	ok := (f.Subject == nil || f.Subject.Match(t.Subject)) &&
+		(f.Predicate == nil || f.Predicate.Match(t.Predicate)) &&
+		(f.Object == nil || f.Object.Match(t.Object))
+	return ok != f.Invert
+}
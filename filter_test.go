@@ -0,0 +1,69 @@
+package ntto
+
+import "testing"
+
+var filterRules = []Rule{
+	Rule{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/"},
+	Rule{Shortcut: "rdfs", Prefix: "http://www.w3.org/2000/01/rdf-schema#"},
+}
+
+var PatternTests = []struct {
+	pattern string
+	in      string
+	out     bool
+}{
+	{"a", "a", true},
+	{"a", "ab", false},
+	{"<a>", "a", true},
+	{"a*", "ab", true},
+	{"a*", "ba", false},
+	{"/b$/", "ab", true},
+	{"/^b/", "ab", false},
+	{"gnd:118540238", "http://d-nb.info/gnd/118540238", true},
+	{"gnd:118540238", "gnd:118540238", true},
+	{"gnd:118540238", "http://d-nb.info/gnd/1185402380", false},
+	{"gnd:1*", "http://d-nb.info/gnd/118540238", true},
+	{"gnd:1*", "gnd:118540238", true},
+	{"foaf:name", "http://xmlns.com/foaf/0.1/name", false},
+	{"rdfs:label", "http://www.w3.org/2000/01/rdf-schema#label", true},
+}
+
+func TestPattern(t *testing.T) {
+	for _, tt := range PatternTests {
+		p, err := ParsePattern(tt.pattern, filterRules)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := p.Match(tt.in); out != tt.out {
+			t.Errorf("ParsePattern(%s).Match(%s) => %v, want: %v", tt.pattern, tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestParsePatternError(t *testing.T) {
+	if _, err := ParsePattern("/(/", nil); err == nil {
+		t.Errorf("ParsePattern(/(/) => no error")
+	}
+}
+
+var FilterTests = []struct {
+	filter Filter
+	triple Triple
+	out    bool
+}{
+	{Filter{}, Triple{Subject: "a", Predicate: "b", Object: "c"}, true},
+	{Filter{Invert: true}, Triple{Subject: "a", Predicate: "b", Object: "c"}, false},
+	{Filter{Subject: &Pattern{values: []string{"a"}}}, Triple{Subject: "a", Predicate: "b", Object: "c"}, true},
+	{Filter{Subject: &Pattern{values: []string{"a"}}, Object: &Pattern{values: []string{"d"}}},
+		Triple{Subject: "a", Predicate: "b", Object: "c"}, false},
+	{Filter{Predicate: &Pattern{values: []string{"b"}}, Invert: true},
+		Triple{Subject: "a", Predicate: "b", Object: "c"}, false},
+}
+
+func TestFilter(t *testing.T) {
+	for _, tt := range FilterTests {
+		if out := tt.filter.Match(&tt.triple); out != tt.out {
+			t.Errorf("Filter(%+v).Match(%s) => %v, want: %v", tt.filter, tt.triple, out, tt.out)
+		}
+	}
+}
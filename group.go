@@ -0,0 +1,90 @@
+package ntto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SubjectEncoder merges consecutive triples with the same subject into a
+// single JSON document per line, like {"@id": s, "p1": [o1, o2], "p2": [o3]}.
+// Predicates keep the order in which they first appear. Literals are written
+// with their N-Triples escapes resolved.
+type SubjectEncoder struct {
+	w          io.Writer
+	emit       func(subject string, doc []byte) error
+	subject    string
+	predicates []string
+	objects    map[string][]interface{}
+	// Typed, if set, writes typed literals as native JSON values.
+	Typed *TypedLiterals
+}
+
+// NewSubjectEncoder returns an encoder writing one document per subject to w.
+func NewSubjectEncoder(w io.Writer) *SubjectEncoder {
+	e := &SubjectEncoder{w: w}
+	e.emit = func(_ string, doc []byte) error {
+		_, err := e.w.Write(doc)
+		return err
+	}
+	return e
+}
+
+// Encode adds a triple to the current document, writing out the previous
+// document, if the subject changed.
+func (e *SubjectEncoder) Encode(t *Triple) error {
+	if e.objects != nil && t.Subject != e.subject {
+		if err := e.writeDocument(); err != nil {
+			return err
+		}
+	}
+	if e.objects == nil {
+		e.subject = t.Subject
+		e.predicates = e.predicates[:0]
+		e.objects = make(map[string][]interface{})
+	}
+	if _, ok := e.objects[t.Predicate]; !ok {
+		e.predicates = append(e.predicates, t.Predicate)
+	}
+	var value interface{} = t.Object
+	if e.Typed != nil {
+		value = e.Typed.Value(t)
+	}
+	if v, ok := value.(string); ok && t.ObjectKind == Literal {
+		value = unescapeLiteral(v)
+	}
+	e.objects[t.Predicate] = append(e.objects[t.Predicate], value)
+	return nil
+}
+
+func (e *SubjectEncoder) writeDocument() error {
+	var buf bytes.Buffer
+	b, err := json.Marshal(e.subject)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&buf, `{"@id":%s`, b)
+	for _, p := range e.predicates {
+		key, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		values, err := json.Marshal(e.objects[p])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, ",%s:%s", key, values)
+	}
+	buf.WriteString("}\n")
+	e.objects = nil
+	return e.emit(e.subject, buf.Bytes())
+}
+
+// Flush writes the last document.
+func (e *SubjectEncoder) Flush() error {
+	if e.objects == nil {
+		return nil
+	}
+	return e.writeDocument()
+}
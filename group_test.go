@@ -0,0 +1,51 @@
+package ntto
+
+import (
+	"bytes"
+	"testing"
+)
+
+var SubjectEncoderTests = []struct {
+	triples []Triple
+	out     string
+}{
+	{nil, ""},
+	{[]Triple{
+		Triple{Subject: "a", Predicate: "p", Object: "1"},
+	}, `{"@id":"a","p":["1"]}
+`},
+	{[]Triple{
+		Triple{Subject: "a", Predicate: "p", Object: "1"},
+		Triple{Subject: "a", Predicate: "q", Object: "2"},
+		Triple{Subject: "a", Predicate: "p", Object: "3"},
+		Triple{Subject: "b", Predicate: "p", Object: "4"},
+		Triple{Subject: "a", Predicate: "p", Object: "5"},
+	}, `{"@id":"a","p":["1","3"],"q":["2"]}
+{"@id":"b","p":["4"]}
+{"@id":"a","p":["5"]}
+`},
+	{[]Triple{
+		Triple{Subject: "a", Predicate: "id", Object: "1"},
+		Triple{Subject: "a", Predicate: "p", Object: `say \"hi\"\n`, ObjectKind: Literal},
+		Triple{Subject: "a", Predicate: "p", Object: `b\"c`},
+	}, `{"@id":"a","id":["1"],"p":["say \"hi\"\n","b\\\"c"]}
+`},
+}
+
+func TestSubjectEncoder(t *testing.T) {
+	for _, tt := range SubjectEncoderTests {
+		var buf bytes.Buffer
+		enc := NewSubjectEncoder(&buf)
+		for i := range tt.triples {
+			if err := enc.Encode(&tt.triples[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("SubjectEncoder(%v) => %s, want: %s", tt.triples, buf.String(), tt.out)
+		}
+	}
+}
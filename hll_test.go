@@ -0,0 +1,34 @@
+package ntto
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestHyperLogLog(t *testing.T) {
+	for _, n := range []int{0, 1, 10, 1000, 100000} {
+		h := NewHyperLogLog(14)
+		for i := 0; i < n; i++ {
+			// every key twice, duplicates must not count
+			h.Add(fmt.Sprintf("http://example.org/%d", i))
+			h.Add(fmt.Sprintf("http://example.org/%d", i))
+		}
+		got := h.Count()
+		if math.Abs(float64(got)-float64(n)) > 0.02*float64(n)+1 {
+			t.Errorf("HyperLogLog(%d).Count() => %d", n, got)
+		}
+	}
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, b := NewHyperLogLog(12), NewHyperLogLog(12)
+	for i := 0; i < 1000; i++ {
+		a.Add(fmt.Sprintf("a%d", i))
+		b.Add(fmt.Sprintf("b%d", i))
+	}
+	a.Merge(b)
+	if got := a.Count(); math.Abs(float64(got)-2000) > 100 {
+		t.Errorf("Merge => %d, want about 2000", got)
+	}
+}
@@ -0,0 +1,152 @@
+package ntto
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Context returns a JSON-LD context that maps the shortcut of each rule to its
+// prefix, so abbreviated IRIs like foaf:name resolve.
+func Context(rules []Rule) map[string]string {
+	context := make(map[string]string)
+	for shortcut, rule := range newPrefixIndex(curieRules(rules)).byShortcut {
+		context[shortcut] = rule.Prefix
+	}
+	return context
+}
+
+// JSONLDEncoder groups consecutive triples with the same subject into node
+// objects and writes them as a single JSON-LD document, one node per line.
+// Expanded form is a plain array of nodes, compacted form uses a context
+// derived from the rules and wraps the nodes into @graph.
+type JSONLDEncoder struct {
+	w       io.Writer
+	rules   []Rule
+	index   *prefixIndex
+	compact bool
+	subject string
+	node    map[string]interface{}
+	started bool
+}
+
+// NewJSONLDEncoder returns a JSON-LD encoder writing to w. Rules are used to
+// expand abbreviated input and, if compact is set, to compact IRIs.
+func NewJSONLDEncoder(w io.Writer, rules []Rule, compact bool) *JSONLDEncoder {
+	return &JSONLDEncoder{w: w, rules: rules, index: newPrefixIndex(curieRules(rules)), compact: compact}
+}
+
+// iri normalizes an IRI into the form required by the output.
+func (e *JSONLDEncoder) iri(s string) string {
+	if strings.HasPrefix(s, "_:") {
+		return s
+	}
+	s = e.index.expand(s)
+	if e.compact {
+		return e.index.compact(s)
+	}
+	return s
+}
+
+// value returns the JSON-LD value object for the object of a triple.
+func (e *JSONLDEncoder) value(t *Triple) interface{} {
+	switch t.ObjectKind {
+	case Literal:
+		v := unescapeLiteral(t.Object)
+		switch {
+		case t.Lang != "":
+			return map[string]string{"@value": v, "@language": t.Lang}
+		case t.Datatype != "":
+			return map[string]string{"@value": v, "@type": e.iri(t.Datatype)}
+		case e.compact:
+			return v
+		}
+		return map[string]string{"@value": v}
+	default:
+		return map[string]string{"@id": e.iri(t.Object)}
+	}
+}
+
+// Encode adds a triple to the current node, writing out the previous node,
+// if the subject changed.
+func (e *JSONLDEncoder) Encode(t *Triple) error {
+	subject := e.iri(t.Subject)
+	if e.node != nil && subject != e.subject {
+		if err := e.writeNode(); err != nil {
+			return err
+		}
+	}
+	if e.node == nil {
+		e.subject = subject
+		e.node = map[string]interface{}{"@id": subject}
+	}
+	predicate := e.index.expand(t.Predicate)
+	if predicate == rdfNS+"type" && t.ObjectKind != Literal {
+		types, _ := e.node["@type"].([]interface{})
+		e.node["@type"] = append(types, e.iri(t.Object))
+		return nil
+	}
+	key := e.iri(predicate)
+	values, _ := e.node[key].([]interface{})
+	e.node[key] = append(values, e.value(t))
+	return nil
+}
+
+func (e *JSONLDEncoder) header() error {
+	if e.started {
+		_, err := io.WriteString(e.w, ",\n")
+		return err
+	}
+	e.started = true
+	if !e.compact {
+		_, err := io.WriteString(e.w, "[\n")
+		return err
+	}
+	b, err := json.Marshal(Context(e.rules))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(e.w, `{"@context":`+string(b)+",\"@graph\":[\n")
+	return err
+}
+
+func (e *JSONLDEncoder) writeNode() error {
+	if e.compact {
+		for k, v := range e.node {
+			if values, ok := v.([]interface{}); ok && len(values) == 1 {
+				e.node[k] = values[0]
+			}
+		}
+	}
+	b, err := json.Marshal(e.node)
+	if err != nil {
+		return err
+	}
+	e.node = nil
+	if err := e.header(); err != nil {
+		return err
+	}
+	_, err = e.w.Write(b)
+	return err
+}
+
+// Flush writes the last node and closes the document.
+func (e *JSONLDEncoder) Flush() error {
+	if e.node != nil {
+		if err := e.writeNode(); err != nil {
+			return err
+		}
+	}
+	footer := "\n]\n"
+	if !e.started {
+		if err := e.header(); err != nil {
+			return err
+		}
+		footer = "]\n"
+	}
+	if e.compact {
+		footer = strings.Replace(footer, "]", "]}", 1)
+	}
+	_, err := io.WriteString(e.w, footer)
+	return err
+}
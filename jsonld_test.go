@@ -0,0 +1,71 @@
+package ntto
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var jsonldRules = []Rule{
+	Rule{Shortcut: "foaf", Prefix: "http://xmlns.com/foaf/0.1/"},
+	Rule{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/"},
+	Rule{Shortcut: "rdf", Prefix: "http://www.w3.org/1999/02/22-rdf-syntax-ns#"},
+}
+
+var jsonldTriples = []Triple{
+	Triple{Subject: "http://d-nb.info/gnd/1", Predicate: "http://www.w3.org/1999/02/22-rdf-syntax-ns#type",
+		Object: "http://xmlns.com/foaf/0.1/Person"},
+	Triple{Subject: "gnd:1", Predicate: "foaf:name", Object: `Alice \"A\"`, ObjectKind: Literal},
+	Triple{Subject: "gnd:1", Predicate: "foaf:name", Object: "Alicia", ObjectKind: Literal, Lang: "es"},
+	Triple{Subject: "_:b0", Predicate: "http://xmlns.com/foaf/0.1/knows", Object: "http://d-nb.info/gnd/1"},
+}
+
+var JSONLDEncoderTests = []struct {
+	triples []Triple
+	compact bool
+	out     string
+}{
+	{jsonldTriples[:1], false,
+		`[
+{"@id":"http://d-nb.info/gnd/1","@type":["http://xmlns.com/foaf/0.1/Person"]}
+]
+`},
+	{jsonldTriples, false,
+		`[
+{"@id":"http://d-nb.info/gnd/1","@type":["http://xmlns.com/foaf/0.1/Person"],"http://xmlns.com/foaf/0.1/name":[{"@value":"Alice \"A\""},{"@language":"es","@value":"Alicia"}]},
+{"@id":"_:b0","http://xmlns.com/foaf/0.1/knows":[{"@id":"http://d-nb.info/gnd/1"}]}
+]
+`},
+	{jsonldTriples, true,
+		`{"@context":{"foaf":"http://xmlns.com/foaf/0.1/","gnd":"http://d-nb.info/gnd/","rdf":"http://www.w3.org/1999/02/22-rdf-syntax-ns#"},"@graph":[
+{"@id":"gnd:1","@type":"foaf:Person","foaf:name":["Alice \"A\"",{"@language":"es","@value":"Alicia"}]},
+{"@id":"_:b0","foaf:knows":{"@id":"gnd:1"}}
+]}
+`},
+	{nil, false, "[\n]\n"},
+	{nil, true, `{"@context":{"foaf":"http://xmlns.com/foaf/0.1/","gnd":"http://d-nb.info/gnd/","rdf":"http://www.w3.org/1999/02/22-rdf-syntax-ns#"},"@graph":[
+]}
+`},
+}
+
+func TestJSONLDEncoder(t *testing.T) {
+	for _, tt := range JSONLDEncoderTests {
+		var buf bytes.Buffer
+		enc := NewJSONLDEncoder(&buf, jsonldRules, tt.compact)
+		for i := range tt.triples {
+			if err := enc.Encode(&tt.triples[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("JSONLDEncoder(%v, compact=%v) => %s, want: %s", tt.triples, tt.compact, buf.String(), tt.out)
+		}
+		var v interface{}
+		if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+			t.Errorf("JSONLDEncoder(%v, compact=%v) => invalid JSON: %s", tt.triples, tt.compact, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package ntto
+
+import "strings"
+
+const rdfLangString = "http://www.w3.org/1999/02/22-rdf-syntax-ns#langString"
+
+// LanguageFilter keeps literals in some languages. IRIs, blank nodes and typed
+// literals always pass.
+type LanguageFilter struct {
+	// Ranges are language ranges in order of preference. A range matches
+	// its tag and all tags starting with it and a hyphen, so de matches
+	// de-CH. The range - matches literals without language tag.
+	Ranges []string
+}
+
+// NewLanguageFilter returns a filter for a comma separated list of language
+// ranges, like de,en,-.
+func NewLanguageFilter(spec string) *LanguageFilter {
+	f := &LanguageFilter{}
+	for _, r := range strings.Split(spec, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			f.Ranges = append(f.Ranges, r)
+		}
+	}
+	return f
+}
+
+// rank returns the position of the first range matching the literal of a
+// triple, and -1 if none does. The boolean is false for triples the filter
+// does not apply to.
+func (f *LanguageFilter) rank(t *Triple) (int, bool) {
+	if t.ObjectKind != Literal || (t.Datatype != "" && t.Datatype != rdfLangString) {
+		return 0, false
+	}
+	for i, r := range f.Ranges {
+		if r == "-" {
+			if t.Lang == "" {
+				return i, true
+			}
+			continue
+		}
+		if strings.EqualFold(t.Lang, r) ||
+			(len(t.Lang) > len(r) && t.Lang[len(r)] == '-' && strings.EqualFold(t.Lang[:len(r)], r)) {
+			return i, true
+		}
+	}
+	return -1, true
+}
+
+// Keep reports whether a triple passes the filter.
+func (f *LanguageFilter) Keep(t *Triple) bool {
+	rank, ok := f.rank(t)
+	return !ok || rank >= 0
+}
+
+// BestLanguage returns an encoder that passes triples on to e, keeping only
+// the literals in the most preferred language available for each subject and
+// predicate. Triples of a subject must be consecutive, since they are
+// buffered until the subject changes.
+func (f *LanguageFilter) BestLanguage(e Encoder) Encoder {
+	return &bestLanguageEncoder{filter: f, encoder: e}
+}
+
+// unfiltered is the rank of triples the filter does not apply to.
+const unfiltered = -2
+
+type bestLanguageEncoder struct {
+	filter  *LanguageFilter
+	encoder Encoder
+	subject string
+	triples []*Triple
+	ranks   []int
+	best    map[string]int
+}
+
+// Encode buffers a triple, passing on the triples of the previous subject.
+func (e *bestLanguageEncoder) Encode(t *Triple) error {
+	if len(e.triples) > 0 && t.Subject != e.subject {
+		if err := e.writeSubject(); err != nil {
+			return err
+		}
+	}
+	if e.best == nil {
+		e.best = make(map[string]int)
+	}
+	e.subject = t.Subject
+	rank, ok := e.filter.rank(t)
+	if !ok {
+		rank = unfiltered
+	} else if rank >= 0 {
+		if best, seen := e.best[t.Predicate]; !seen || rank < best {
+			e.best[t.Predicate] = rank
+		}
+	}
+	e.triples = append(e.triples, t)
+	e.ranks = append(e.ranks, rank)
+	return nil
+}
+
+// writeSubject passes on the buffered triples that are not literals, or
+// literals in the best language of their predicate.
+func (e *bestLanguageEncoder) writeSubject() error {
+	for i, t := range e.triples {
+		rank := e.ranks[i]
+		if rank == unfiltered || (rank >= 0 && rank == e.best[t.Predicate]) {
+			if err := e.encoder.Encode(t); err != nil {
+				return err
+			}
+		}
+	}
+	e.triples, e.ranks = e.triples[:0], e.ranks[:0]
+	for p := range e.best {
+		delete(e.best, p)
+	}
+	return nil
+}
+
+// Flush writes the triples of the last subject and flushes e.
+func (e *bestLanguageEncoder) Flush() error {
+	if err := e.writeSubject(); err != nil {
+		return err
+	}
+	return e.encoder.Flush()
+}
@@ -0,0 +1,76 @@
+package ntto
+
+import (
+	"bytes"
+	"testing"
+)
+
+const languageInput = `<http://a> <http://label> "Berlin"@de .
+<http://a> <http://label> "Berlin"@en .
+<http://a> <http://label> "Berlín"@es .
+<http://a> <http://label> "Berlin" .
+<http://a> <http://population> "3645000"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://a> <http://type> <http://City> .
+<http://a> <http://comment> "Hauptstadt"@de-DE .
+<http://a> <http://comment> "capital"@en .
+<http://b> <http://label> "Paris"@fr .
+<http://b> <http://label> "Paris"@en .
+`
+
+var LanguageFilterTests = []struct {
+	spec string
+	best bool
+	out  string
+}{
+	{"de,en,-", false, `<http://a> <http://label> "Berlin"@de .
+<http://a> <http://label> "Berlin"@en .
+<http://a> <http://label> "Berlin" .
+<http://a> <http://population> "3645000"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://a> <http://type> <http://City> .
+<http://a> <http://comment> "Hauptstadt"@de-DE .
+<http://a> <http://comment> "capital"@en .
+<http://b> <http://label> "Paris"@en .
+`},
+	{"es", false, `<http://a> <http://label> "Berlín"@es .
+<http://a> <http://population> "3645000"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://a> <http://type> <http://City> .
+`},
+	{"de,en,-", true, `<http://a> <http://label> "Berlin"@de .
+<http://a> <http://population> "3645000"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://a> <http://type> <http://City> .
+<http://a> <http://comment> "Hauptstadt"@de-DE .
+<http://b> <http://label> "Paris"@en .
+`},
+	{"-,en", true, `<http://a> <http://label> "Berlin" .
+<http://a> <http://population> "3645000"^^<http://www.w3.org/2001/XMLSchema#integer> .
+<http://a> <http://type> <http://City> .
+<http://a> <http://comment> "capital"@en .
+<http://b> <http://label> "Paris"@en .
+`},
+}
+
+func TestLanguageFilter(t *testing.T) {
+	triples := parseDump(t, languageInput)
+	for _, tt := range LanguageFilterTests {
+		var buf bytes.Buffer
+		filter := NewLanguageFilter(tt.spec)
+		var enc Encoder = NewNTriplesEncoder(&buf)
+		if tt.best {
+			enc = filter.BestLanguage(enc)
+		}
+		for _, triple := range triples {
+			if !filter.Keep(triple) {
+				continue
+			}
+			if err := enc.Encode(triple); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := enc.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("%s, best %v: got\n%s\nwant\n%s", tt.spec, tt.best, buf.String(), tt.out)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package ntto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LintRules checks a rules file. Errors are rules that cannot work as
+// intended: broken lines, relative prefixes and shortcuts used for two
+// prefixes, which would make abbreviations ambiguous. Warnings are rules that
+// work, but are most likely not what was meant. Shortcuts equal to null
+// remove their prefix and are exempt from shortcut checks.
+func LintRules(s, null string) []Problem {
+	return lintRules(s, null, nil)
+}
+
+// LintDefaultRules checks DefaultRules like LintRules. The legacy pack is kept
+// as it was, so its shortcuts used for two prefixes are only warnings.
+func LintDefaultRules(null string) []Problem {
+	legacy, _ := Pack("legacy")
+	rules, _ := ParseRules(legacy)
+	kept := make(map[Rule]bool)
+	for _, r := range rules {
+		kept[r] = true
+	}
+	return lintRules(DefaultRules, null, func(r Rule) bool { return kept[r] })
+}
+
+// lintRules implements LintRules. Shortcuts used twice are errors, unless
+// compatible reports the later rule as one kept for compatibility.
+func lintRules(s, null string, compatible func(Rule) bool) []Problem {
+	var problems []Problem
+	report := func(line int, severity, format string, a ...interface{}) {
+		problems = append(problems, Problem{
+			Line:     line,
+			Column:   1,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, a...),
+		})
+	}
+	type seenRule struct {
+		rule Rule
+		line int
+	}
+	var previous []seenRule
+	byShortcut := make(map[string]seenRule)
+	byPrefix := make(map[string]seenRule)
+	for i, line := range strings.Split(s, "\n") {
+		n := i + 1
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			report(n, SeverityError, "broken rule: %s", line)
+			continue
+		}
+		if len(fields) > 2 {
+			report(n, SeverityWarning, "ignoring %q after prefix", strings.Join(fields[2:], " "))
+		}
+		rule := Rule{Shortcut: fields[0], Prefix: fields[1]}
+
+		if !iriScheme.MatchString(rule.Prefix) {
+			report(n, SeverityError, "prefix %s is not an absolute IRI", rule.Prefix)
+		} else if !strings.ContainsAny(rule.Prefix[len(rule.Prefix)-1:], "/#:") {
+			report(n, SeverityWarning, "prefix %s does not end with /, # or :", rule.Prefix)
+		}
+		if rule.Shortcut != null && !validShortcut.MatchString(rule.Shortcut) {
+			report(n, SeverityWarning, "shortcut %s cannot be used in CURIEs or JSON-LD", rule.Shortcut)
+		}
+
+		if p, ok := byPrefix[rule.Prefix]; ok {
+			if p.rule == rule {
+				report(n, SeverityWarning, "duplicate of rule on line %d", p.line)
+			} else {
+				report(n, SeverityWarning, "prefix %s is already abbreviated as %s on line %d, this rule never matches",
+					rule.Prefix, p.rule.Shortcut, p.line)
+			}
+		} else {
+			byPrefix[rule.Prefix] = seenRule{rule, n}
+		}
+		if p, ok := byShortcut[rule.Shortcut]; ok && rule.Shortcut != null && p.rule.Prefix != rule.Prefix {
+			severity := SeverityError
+			if compatible != nil && compatible(rule) {
+				severity = SeverityWarning
+			}
+			report(n, severity, "shortcut %s is already used for %s on line %d",
+				rule.Shortcut, p.rule.Prefix, p.line)
+		} else if !ok {
+			byShortcut[rule.Shortcut] = seenRule{rule, n}
+		}
+		for _, p := range previous {
+			if p.rule.Prefix != rule.Prefix && strings.HasPrefix(rule.Prefix, p.rule.Prefix) {
+				report(n, SeverityWarning, "shadowed by %s on line %d when abbreviating with perl or replace",
+					p.rule.Prefix, p.line)
+				break
+			}
+		}
+		previous = append(previous, seenRule{rule, n})
+	}
+	return problems
+}
@@ -0,0 +1,40 @@
+package ntto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	rules := `
+# comment
+gnd      http://d-nb.info/gnd/
+broken
+dnb      http://d-nb.info/standards/elementset/gnd# extra
+gnd      http://d-nb.info/gnd/
+gnd2     http://d-nb.info/gnd/
+gnd      http://example.org/
+x        relative/
+gndsub   http://d-nb.info/gnd/sub/
+urn      urn:isbn:
+la       http://example.org/label
+my:x     http://example.com/
+<NULL>   http://null.org/
+<NULL>   http://other-null.org/
+`
+	want := []Problem{
+		{Line: 4, Column: 1, Severity: SeverityError, Message: "broken rule: broken"},
+		{Line: 5, Column: 1, Severity: SeverityWarning, Message: `ignoring "extra" after prefix`},
+		{Line: 6, Column: 1, Severity: SeverityWarning, Message: "duplicate of rule on line 3"},
+		{Line: 7, Column: 1, Severity: SeverityWarning, Message: "prefix http://d-nb.info/gnd/ is already abbreviated as gnd on line 3, this rule never matches"},
+		{Line: 8, Column: 1, Severity: SeverityError, Message: "shortcut gnd is already used for http://d-nb.info/gnd/ on line 3"},
+		{Line: 9, Column: 1, Severity: SeverityError, Message: "prefix relative/ is not an absolute IRI"},
+		{Line: 10, Column: 1, Severity: SeverityWarning, Message: "shadowed by http://d-nb.info/gnd/ on line 3 when abbreviating with perl or replace"},
+		{Line: 12, Column: 1, Severity: SeverityWarning, Message: "prefix http://example.org/label does not end with /, # or :"},
+		{Line: 12, Column: 1, Severity: SeverityWarning, Message: "shadowed by http://example.org/ on line 8 when abbreviating with perl or replace"},
+		{Line: 13, Column: 1, Severity: SeverityWarning, Message: "shortcut my:x cannot be used in CURIEs or JSON-LD"},
+	}
+	if out := LintRules(rules, "<NULL>"); !reflect.DeepEqual(out, want) {
+		t.Errorf("LintRules =>\n%v\nwant:\n%v", out, want)
+	}
+}
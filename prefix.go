@@ -0,0 +1,88 @@
+package ntto
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validShortcut matches shortcuts that can be used as a CURIE prefix.
+var validShortcut = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// curieRules returns the rules whose shortcut can be used in a CURIE.
+func curieRules(rules []Rule) []Rule {
+	var result []Rule
+	for _, rule := range rules {
+		if validShortcut.MatchString(rule.Shortcut) {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// prefixIndex looks up rules by prefix and by shortcut. If several rules share
+// a prefix or a shortcut, the first one wins.
+type prefixIndex struct {
+	byPrefix   map[string]Rule
+	byShortcut map[string]Rule
+	// lengths holds the distinct prefix lengths, longest first
+	lengths []int
+}
+
+func newPrefixIndex(rules []Rule) *prefixIndex {
+	x := &prefixIndex{
+		byPrefix:   make(map[string]Rule),
+		byShortcut: make(map[string]Rule),
+	}
+	seen := make(map[int]bool)
+	for _, rule := range rules {
+		if rule.Prefix == "" {
+			continue
+		}
+		if _, ok := x.byPrefix[rule.Prefix]; !ok {
+			x.byPrefix[rule.Prefix] = rule
+		}
+		if _, ok := x.byShortcut[rule.Shortcut]; !ok {
+			x.byShortcut[rule.Shortcut] = rule
+		}
+		if !seen[len(rule.Prefix)] {
+			seen[len(rule.Prefix)] = true
+			x.lengths = append(x.lengths, len(rule.Prefix))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(x.lengths)))
+	return x
+}
+
+// match returns the rule with the longest prefix of iri.
+func (x *prefixIndex) match(iri string) (Rule, bool) {
+	for _, n := range x.lengths {
+		if n > len(iri) {
+			continue
+		}
+		if rule, ok := x.byPrefix[iri[:n]]; ok {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// compact turns an IRI into a CURIE, if there is a rule for it.
+func (x *prefixIndex) compact(iri string) string {
+	if rule, ok := x.match(iri); ok {
+		return rule.Shortcut + ":" + iri[len(rule.Prefix):]
+	}
+	return iri
+}
+
+// expand turns a CURIE back into an IRI, if there is a rule for its shortcut.
+func (x *prefixIndex) expand(curie string) string {
+	i := strings.Index(curie, ":")
+	if i < 0 {
+		return curie
+	}
+	if rule, ok := x.byShortcut[curie[:i]]; ok {
+		return rule.Prefix + curie[i+1:]
+	}
+	return curie
+}
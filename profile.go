@@ -0,0 +1,107 @@
+package ntto
+
+import (
+	"sort"
+	"strings"
+)
+
+// BuiltinProfiles returns the names of the built-in rule profiles, sorted:
+// one per pack, and default for all packs.
+func BuiltinProfiles() []string {
+	names := append([]string{"default"}, Packs...)
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinProfile returns the rules of a built-in profile, and false if there
+// is no profile with that name.
+func BuiltinProfile(name string) ([]Rule, bool) {
+	s, ok := DefaultRules, true
+	if name != "default" {
+		s, ok = Pack(name)
+	}
+	if !ok {
+		return nil, false
+	}
+	rules, err := ParseRules(s)
+	if err != nil {
+		panic(err)
+	}
+	return rules, true
+}
+
+// RuleLayer is a set of rules from one source, like a profile or a file.
+type RuleLayer struct {
+	Name  string
+	Rules []Rule
+}
+
+// LayeredRule is a rule together with the name of the layer it came from.
+type LayeredRule struct {
+	Rule
+	Layer string
+}
+
+// MergeRuleLayers combines layers in order. A rule overrides the rules of
+// earlier layers with the same shortcut or the same prefix, so a project can
+// remap a shortcut or a namespace without editing the layers below. Rules
+// whose shortcut cannot be used in CURIEs, like the null shortcut, only
+// override by prefix. Rules within a layer never override each other, and a
+// rule repeated unchanged keeps the layer it first came from.
+func MergeRuleLayers(layers []RuleLayer) []LayeredRule {
+	var merged []LayeredRule
+	for _, layer := range layers {
+		rules := make(map[Rule]bool)
+		shortcuts := make(map[string]bool)
+		prefixes := make(map[string]bool)
+		for _, rule := range layer.Rules {
+			rules[rule] = true
+			if validShortcut.MatchString(rule.Shortcut) {
+				shortcuts[rule.Shortcut] = true
+			}
+			prefixes[rule.Prefix] = true
+		}
+		kept := merged[:0]
+		existing := make(map[Rule]bool)
+		for _, r := range merged {
+			if rules[r.Rule] || (!shortcuts[r.Shortcut] && !prefixes[r.Prefix]) {
+				kept = append(kept, r)
+				existing[r.Rule] = true
+			}
+		}
+		merged = kept
+		for _, rule := range layer.Rules {
+			if !existing[rule] {
+				merged = append(merged, LayeredRule{Rule: rule, Layer: layer.Name})
+			}
+		}
+	}
+	return merged
+}
+
+// Rules returns the rules of a merge, without their layers.
+func Rules(layered []LayeredRule) []Rule {
+	rules := make([]Rule, len(layered))
+	for i, r := range layered {
+		rules[i] = r.Rule
+	}
+	return rules
+}
+
+// DumpRuleLayers formats merged rules like DumpRules, grouped by the layer
+// they came from, with the layer name as a comment before each group.
+func DumpRuleLayers(layered []LayeredRule) string {
+	var names []string
+	byLayer := make(map[string][]Rule)
+	for _, r := range layered {
+		if _, ok := byLayer[r.Layer]; !ok {
+			names = append(names, r.Layer)
+		}
+		byLayer[r.Layer] = append(byLayer[r.Layer], r.Rule)
+	}
+	var groups []string
+	for _, name := range names {
+		groups = append(groups, "# "+name+"\n"+DumpRules(byLayer[name]))
+	}
+	return strings.Join(groups, "\n\n")
+}
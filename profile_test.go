@@ -0,0 +1,68 @@
+package ntto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeRuleLayers(t *testing.T) {
+	layers := []RuleLayer{
+		{Name: "base", Rules: []Rule{
+			{Shortcut: "a", Prefix: "http://a.org/"},
+			{Shortcut: "b", Prefix: "http://b.org/"},
+			{Shortcut: "c", Prefix: "http://c.org/"},
+			{Shortcut: "<NULL>", Prefix: "http://null.org/"},
+		}},
+		{Name: "project", Rules: []Rule{
+			{Shortcut: "b", Prefix: "http://example.org/b/"},
+			{Shortcut: "cc", Prefix: "http://c.org/"},
+			{Shortcut: "<NULL>", Prefix: "http://other.org/"},
+			{Shortcut: "a", Prefix: "http://a.org/"},
+		}},
+	}
+	want := []LayeredRule{
+		{Rule{Shortcut: "a", Prefix: "http://a.org/"}, "base"},
+		{Rule{Shortcut: "<NULL>", Prefix: "http://null.org/"}, "base"},
+		{Rule{Shortcut: "b", Prefix: "http://example.org/b/"}, "project"},
+		{Rule{Shortcut: "cc", Prefix: "http://c.org/"}, "project"},
+		{Rule{Shortcut: "<NULL>", Prefix: "http://other.org/"}, "project"},
+	}
+	merged := MergeRuleLayers(layers)
+	if !reflect.DeepEqual(merged, want) {
+		t.Errorf("got %v, want %v", merged, want)
+	}
+
+	dump := "# base\n<NULL>\thttp://null.org/\na\thttp://a.org/\n\n" +
+		"# project\n<NULL>\thttp://other.org/\nb\thttp://example.org/b/\ncc\thttp://c.org/"
+	if got := DumpRuleLayers(merged); got != dump {
+		t.Errorf("got %q, want %q", got, dump)
+	}
+}
+
+func TestBuiltinProfile(t *testing.T) {
+	all, ok := BuiltinProfile("default")
+	if !ok || len(all) == 0 {
+		t.Fatal("missing default profile")
+	}
+	glam, ok := BuiltinProfile("glam")
+	if !ok {
+		t.Fatal("missing glam profile")
+	}
+	shortcuts := make(map[string]bool)
+	for _, rule := range glam {
+		shortcuts[rule.Shortcut] = true
+	}
+	for _, s := range []string{"gnd", "viaf", "bibo", "frbr"} {
+		if !shortcuts[s] {
+			t.Errorf("missing %s in glam profile", s)
+		}
+	}
+	for _, name := range BuiltinProfiles() {
+		if rules, ok := BuiltinProfile(name); !ok || len(rules) == 0 {
+			t.Errorf("empty profile: %s", name)
+		}
+	}
+	if _, ok := BuiltinProfile("unknown"); ok {
+		t.Error("unknown profile found")
+	}
+}
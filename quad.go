@@ -0,0 +1,158 @@
+package ntto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Quad is a triple with the graph it belongs to. Graph is empty for the
+// default graph, so every N-Triples line is also an N-Quads line.
+type Quad struct {
+	Triple
+	Graph string `json:"g,omitempty" xml:"g,omitempty"`
+}
+
+// String returns the quad as an N-Quads line, without a trailing newline.
+func (q Quad) String() string {
+	if q.Graph == "" {
+		return q.Triple.String()
+	}
+	return fmt.Sprintf("%s <%s> %s %s .", formatSubject(q.Subject), q.Predicate,
+		q.formatObject(), formatSubject(q.Graph))
+}
+
+// ParseNQuad parses an N-Quads line. Lines without a graph label end up in
+// the default graph. Terms are scanned one by one, so literals keep their
+// exact contents, including runs of whitespace and words that look like
+// terms.
+func ParseNQuad(line string) (*Quad, error) {
+	terms, _, err := scanNQuad(line)
+	if err != nil {
+		return nil, err
+	}
+	var graph string
+	if len(terms) == 4 {
+		graph = strings.Trim(terms[3], "<>")
+	}
+	t := &Triple{
+		Subject:   strings.Trim(terms[0], "<>"),
+		Predicate: strings.Trim(terms[1], "<>"),
+	}
+	parseObject(terms[2], t)
+	return &Quad{Triple: *t, Graph: graph}, nil
+}
+
+// MapNQuadTerms returns an N-Quads line with each term replaced by f, which
+// gets the position of the term, 0 for the subject up to 3 for the graph, and
+// the term as written, like <iri>, _:b0 or "x"@en. Everything else in the
+// line stays as it is.
+func MapNQuadTerms(line string, f func(pos int, term string) string) (string, error) {
+	terms, offsets, err := scanNQuad(line)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	last := 0
+	for i, term := range terms {
+		sb.WriteString(line[last:offsets[i]])
+		sb.WriteString(f(i, term))
+		last = offsets[i] + len(term)
+	}
+	sb.WriteString(line[last:])
+	return sb.String(), nil
+}
+
+// scanNQuad returns the three or four terms of an N-Quads line verbatim,
+// with their offsets in the line.
+func scanNQuad(line string) ([]string, []int, error) {
+	var terms []string
+	var offsets []int
+	i := 0
+	for {
+		i = skipSpace(line, i)
+		if i == len(line) || (line[i] == '.' && skipSpace(line, i+1) == len(line)) {
+			break
+		}
+		if len(terms) == 4 {
+			return nil, nil, fmt.Errorf("broken input, trailing data: %s", line)
+		}
+		term, next, err := scanTerm(line, i)
+		if err != nil {
+			return nil, nil, err
+		}
+		terms, offsets = append(terms, term), append(offsets, i)
+		i = next
+	}
+	if len(terms) < 3 {
+		return nil, nil, fmt.Errorf("broken input: %s", terms)
+	}
+	if len(terms) == 4 && !isGraphLabel(terms[3]) {
+		return nil, nil, fmt.Errorf("broken input, invalid graph label: %s", terms[3])
+	}
+	return terms, offsets, nil
+}
+
+// skipSpace returns the index of the first non-blank byte at or after i.
+func skipSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// scanTerm returns the term starting at i verbatim, with the index after it:
+// an IRI in angle brackets, a literal in quotes, with escapes and an optional
+// language tag or datatype, or a bare word like a blank node label. A dot
+// ending a bare word belongs to the statement, not to the term.
+func scanTerm(s string, i int) (string, int, error) {
+	start := i
+	switch s[i] {
+	case '<':
+		j := strings.IndexByte(s[i:], '>')
+		if j < 0 {
+			return "", 0, fmt.Errorf("broken input, unterminated IRI: %s", s[i:])
+		}
+		return s[i : i+j+1], i + j + 1, nil
+	case '"':
+		for i++; ; i++ {
+			if i >= len(s) {
+				return "", 0, fmt.Errorf("broken input, unterminated literal: %s", s[start:])
+			}
+			if s[i] == '\\' {
+				i++
+				continue
+			}
+			if s[i] == '"' {
+				break
+			}
+		}
+		i++
+		if strings.HasPrefix(s[i:], "^^<") {
+			j := strings.IndexByte(s[i:], '>')
+			if j < 0 {
+				return "", 0, fmt.Errorf("broken input, unterminated datatype: %s", s[start:])
+			}
+			i += j + 1
+		} else if i < len(s) && s[i] == '@' {
+			for i++; i < len(s) && (isAlnum(s[i]) || s[i] == '-'); i++ {
+			}
+		}
+		return s[start:i], i, nil
+	}
+	for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+		i++
+	}
+	end := i
+	for end > start+1 && s[end-1] == '.' {
+		end--
+	}
+	return s[start:end], end, nil
+}
+
+// isGraphLabel reports whether a term is an IRI or a blank node label.
+func isGraphLabel(term string) bool {
+	if strings.HasPrefix(term, "_:") {
+		return true
+	}
+	return strings.HasPrefix(term, "<") && strings.HasSuffix(term, ">")
+}
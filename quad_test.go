@@ -0,0 +1,97 @@
+package ntto
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var ParseNQuadTests = []struct {
+	in  string
+	out *Quad
+}{
+	{`<a> <b> <c> .`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "c"}}},
+	{`<a> <b> <c> <g> .`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "c"}, Graph: "g"}},
+	{`<a> <b> <c> <g>.`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "c"}, Graph: "g"}},
+	{`<a> <b> "x y"@en _:g .`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "x y", ObjectKind: Literal, Lang: "en"}, Graph: "_:g"}},
+	{`<a> <b> "see <c>" .`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "see <c>", ObjectKind: Literal}}},
+	{`<a> <b> "1 2"^^<d>.`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "1 2", ObjectKind: Literal, Datatype: "d"}}},
+	{"<a> <b> \"x   y\tz\" .", &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "x   y\tz", ObjectKind: Literal}}},
+	{`<a> <b> "tail _:b1" .`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: "tail _:b1", ObjectKind: Literal}}},
+	{`<a> <b> "say \"<c> _:x\" ." <g> .`, &Quad{Triple: Triple{Subject: "a", Predicate: "b", Object: `say \"<c> _:x\" .`, ObjectKind: Literal}, Graph: "g"}},
+	{"_:a\t<b>\t_:c\t_:g.", &Quad{Triple: Triple{Subject: "_:a", Predicate: "b", Object: "_:c", ObjectKind: BlankNode}, Graph: "_:g"}},
+}
+
+func TestParseNQuadErrors(t *testing.T) {
+	for _, in := range []string{
+		`<a> <b> .`,
+		`<a> <b> "open .`,
+		`<a> <b> <c> "g" .`,
+		`<a> <b> <c> <g> <h> .`,
+		`<a> <b> <c`,
+	} {
+		if _, err := ParseNQuad(in); err == nil {
+			t.Errorf("ParseNQuad(%s): expected error", in)
+		}
+	}
+}
+
+func TestParseNQuad(t *testing.T) {
+	for _, tt := range ParseNQuadTests {
+		out, err := ParseNQuad(tt.in)
+		if err != nil {
+			t.Fatalf("ParseNQuad(%s) failed: %s", tt.in, err)
+		}
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("ParseNQuad(%s) => %+v, want: %+v", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestQuadString(t *testing.T) {
+	for _, in := range []string{
+		`<a> <b> <c> .`,
+		`<a> <b> <c> <g> .`,
+		`_:a <b> "x"@en _:g .`,
+		"<a> <b> \"x   y\tz\" .",
+		`<a> <b> "tail _:b1" .`,
+	} {
+		q, err := ParseNQuad(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if q.String() != in {
+			t.Errorf("ParseNQuad(%s).String() => %s", in, q.String())
+		}
+	}
+}
+
+var MapNQuadTermsTests = []struct {
+	in  string
+	out string
+}{
+	{`<a> <b> <c> .`, `<a> <b> <c> .`},
+	{"_:a  <b>\t\"x   _:y\"@en _:g .", "_:Xa  <b>\t\"x   _:y\"@en _:Xg ."},
+	{`_:a <b> _:c<g>.`, `_:Xa <b> _:Xc<g>.`},
+}
+
+func TestMapNQuadTerms(t *testing.T) {
+	f := func(pos int, term string) string {
+		if strings.HasPrefix(term, "_:") {
+			return "_:X" + term[2:]
+		}
+		return term
+	}
+	for _, tt := range MapNQuadTermsTests {
+		out, err := MapNQuadTerms(tt.in, f)
+		if err != nil {
+			t.Fatalf("MapNQuadTerms(%s) failed: %s", tt.in, err)
+		}
+		if out != tt.out {
+			t.Errorf("MapNQuadTerms(%s) => %s, want: %s", tt.in, out, tt.out)
+		}
+	}
+	if _, err := MapNQuadTerms(`<a> <b> "open .`, f); err == nil {
+		t.Errorf("MapNQuadTerms: expected error")
+	}
+}
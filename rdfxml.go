@@ -0,0 +1,358 @@
+package ntto
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+const xmlNS = "http://www.w3.org/XML/1998/namespace"
+
+// frameKind tells what an open element stands for.
+type frameKind int
+
+const (
+	frameRoot frameKind = iota
+	frameNode
+	frameProperty
+	frameResource
+	frameCollection
+)
+
+// frame is an open element in an RDF/XML document.
+type frame struct {
+	kind    frameKind
+	subject string
+	base    string
+	lang    string
+	li      int
+	// property elements only
+	predicate string
+	datatype  string
+	text      strings.Builder
+	object    bool
+	items     []term
+}
+
+// RDFXMLParser reads RDF/XML with the token API of encoding/xml and emits one
+// triple at a time, so documents never need to fit into memory. Reification
+// via rdf:ID on property elements is not supported.
+type RDFXMLParser struct {
+	dec     *xml.Decoder
+	stack   []*frame
+	pending []*Triple
+	base    string
+	genid   int
+}
+
+// NewRDFXMLParser returns a parser reading from r. Relative IRIs are resolved
+// against base, unless the document sets xml:base.
+func NewRDFXMLParser(r io.Reader, base string) *RDFXMLParser {
+	return &RDFXMLParser{dec: xml.NewDecoder(r), base: base}
+}
+
+// Next returns the next triple or io.EOF, if there are no more triples.
+func (p *RDFXMLParser) Next() (*Triple, error) {
+	for len(p.pending) == 0 {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			err = p.start(tok)
+		case xml.EndElement:
+			p.end()
+		case xml.CharData:
+			if top := p.top(); top != nil && top.kind == frameProperty {
+				top.text.Write(tok)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	t := p.pending[0]
+	p.pending = p.pending[1:]
+	return t, nil
+}
+
+func (p *RDFXMLParser) errorf(format string, a ...interface{}) error {
+	line, _ := p.dec.InputPos()
+	return fmt.Errorf("rdfxml: line %d: %s", line, fmt.Sprintf(format, a...))
+}
+
+func (p *RDFXMLParser) top() *frame {
+	if len(p.stack) == 0 {
+		return nil
+	}
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *RDFXMLParser) emit(s, pred string, o term) {
+	p.pending = append(p.pending, &Triple{
+		Subject:    s,
+		Predicate:  pred,
+		Object:     o.value,
+		ObjectKind: o.kind,
+		Lang:       o.lang,
+		Datatype:   o.datatype,
+	})
+}
+
+func (p *RDFXMLParser) blank() string {
+	p.genid++
+	return fmt.Sprintf("_:genid%d", p.genid)
+}
+
+// resolveIRI resolves a reference against a base IRI, if possible.
+func resolveIRI(base, ref string) string {
+	if base == "" {
+		return ref
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	u, err := url.Parse(ref)
+	if err != nil || u.IsAbs() {
+		return ref
+	}
+	return b.ResolveReference(u).String()
+}
+
+// rdfAttrs holds the syntax attributes of an element, other attributes are
+// property attributes.
+type rdfAttrs struct {
+	about, id, nodeID, resource, datatype, parseType string
+	hasAbout, hasID, hasNodeID, hasResource          bool
+	properties                                       []xml.Attr
+}
+
+func splitAttrs(attrs []xml.Attr) rdfAttrs {
+	var a rdfAttrs
+	for _, attr := range attrs {
+		if attr.Name.Space == "xmlns" || (attr.Name.Space == "" && attr.Name.Local == "xmlns") ||
+			attr.Name.Space == xmlNS || attr.Name.Space == "" {
+			continue
+		}
+		if attr.Name.Space != rdfNS {
+			a.properties = append(a.properties, attr)
+			continue
+		}
+		switch attr.Name.Local {
+		case "about":
+			a.about, a.hasAbout = attr.Value, true
+		case "ID":
+			a.id, a.hasID = attr.Value, true
+		case "nodeID":
+			a.nodeID, a.hasNodeID = attr.Value, true
+		case "resource":
+			a.resource, a.hasResource = attr.Value, true
+		case "datatype":
+			a.datatype = attr.Value
+		case "parseType":
+			a.parseType = attr.Value
+		default:
+			a.properties = append(a.properties, attr)
+		}
+	}
+	return a
+}
+
+// inherit returns base and language of an element, given its parent.
+func inherit(parent *frame, fallback string, attrs []xml.Attr) (base, lang string) {
+	base = fallback
+	if parent != nil {
+		base, lang = parent.base, parent.lang
+	}
+	for _, attr := range attrs {
+		if attr.Name.Space != xmlNS {
+			continue
+		}
+		switch attr.Name.Local {
+		case "base":
+			base = resolveIRI(base, attr.Value)
+		case "lang":
+			lang = attr.Value
+		}
+	}
+	return base, lang
+}
+
+func (p *RDFXMLParser) start(el xml.StartElement) error {
+	parent := p.top()
+	base, lang := inherit(parent, p.base, el.Attr)
+	name := el.Name.Space + el.Name.Local
+	if parent == nil && name == rdfNS+"RDF" {
+		p.stack = append(p.stack, &frame{kind: frameRoot, base: base, lang: lang})
+		return nil
+	}
+	if parent == nil || parent.kind == frameRoot || parent.kind == frameProperty || parent.kind == frameCollection {
+		return p.startNode(parent, el, name, base, lang)
+	}
+	return p.startProperty(parent, el, name, base, lang)
+}
+
+func (p *RDFXMLParser) startNode(parent *frame, el xml.StartElement, name, base, lang string) error {
+	a := splitAttrs(el.Attr)
+	var subject term
+	switch {
+	case a.hasAbout:
+		subject = term{value: resolveIRI(base, a.about)}
+	case a.hasID:
+		subject = term{value: resolveIRI(base, "#"+a.id)}
+	case a.hasNodeID:
+		subject = term{value: documentBlankNode(a.nodeID), kind: BlankNode}
+	default:
+		subject = term{value: p.blank(), kind: BlankNode}
+	}
+	if parent != nil {
+		switch parent.kind {
+		case frameProperty:
+			if parent.object {
+				return p.errorf("more than one node in property element %s", parent.predicate)
+			}
+			parent.object = true
+			p.emit(parent.subject, parent.predicate, subject)
+		case frameCollection:
+			parent.items = append(parent.items, subject)
+		}
+	}
+	if name != rdfNS+"Description" {
+		p.emit(subject.value, rdfNS+"type", term{value: name})
+	}
+	p.propertyAttrs(subject.value, a.properties, base, lang)
+	p.stack = append(p.stack, &frame{kind: frameNode, subject: subject.value, base: base, lang: lang})
+	return nil
+}
+
+// propertyAttrs emits a triple for each property attribute.
+func (p *RDFXMLParser) propertyAttrs(subject string, attrs []xml.Attr, base, lang string) {
+	for _, attr := range attrs {
+		predicate := attr.Name.Space + attr.Name.Local
+		if predicate == rdfNS+"type" {
+			p.emit(subject, predicate, term{value: resolveIRI(base, attr.Value)})
+			continue
+		}
+		p.emit(subject, predicate, term{value: escapeLiteral(attr.Value), kind: Literal, lang: lang})
+	}
+}
+
+func (p *RDFXMLParser) startProperty(parent *frame, el xml.StartElement, name, base, lang string) error {
+	if name == rdfNS+"li" {
+		parent.li++
+		name = fmt.Sprintf("%s_%d", rdfNS, parent.li)
+	}
+	a := splitAttrs(el.Attr)
+	f := &frame{kind: frameProperty, subject: parent.subject, predicate: name, base: base, lang: lang}
+	switch a.parseType {
+	case "":
+	case "Resource":
+		node := p.blank()
+		p.emit(parent.subject, name, term{value: node, kind: BlankNode})
+		f.kind, f.subject = frameResource, node
+		p.stack = append(p.stack, f)
+		return nil
+	case "Collection":
+		f.kind = frameCollection
+		p.stack = append(p.stack, f)
+		return nil
+	default:
+		// Literal and unknown parse types keep the content as XML literal
+		s, err := p.innerXML()
+		if err != nil {
+			return err
+		}
+		p.emit(parent.subject, name, term{value: escapeLiteral(s), kind: Literal, datatype: rdfNS + "XMLLiteral"})
+		// innerXML consumed the end element
+		return nil
+	}
+	if a.hasResource || a.hasNodeID || len(a.properties) > 0 {
+		var o term
+		switch {
+		case a.hasResource:
+			o = term{value: resolveIRI(base, a.resource)}
+		case a.hasNodeID:
+			o = term{value: documentBlankNode(a.nodeID), kind: BlankNode}
+		default:
+			o = term{value: p.blank(), kind: BlankNode}
+		}
+		p.emit(parent.subject, name, o)
+		p.propertyAttrs(o.value, a.properties, base, lang)
+		f.object = true
+	}
+	if a.datatype != "" {
+		f.datatype = resolveIRI(base, a.datatype)
+	}
+	p.stack = append(p.stack, f)
+	return nil
+}
+
+// innerXML reads the content of the current element up to and including its
+// end element and returns the content serialized as XML.
+func (p *RDFXMLParser) innerXML() (string, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	depth := 0
+	for {
+		tok, err := p.dec.Token()
+		if err != nil {
+			return "", err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			if depth == 0 {
+				if err := enc.Flush(); err != nil {
+					return "", err
+				}
+				return buf.String(), nil
+			}
+			depth--
+		}
+		if err := enc.EncodeToken(tok); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *RDFXMLParser) end() {
+	f := p.top()
+	if f == nil {
+		return
+	}
+	p.stack = p.stack[:len(p.stack)-1]
+	switch f.kind {
+	case frameProperty:
+		if f.object {
+			return
+		}
+		o := term{value: escapeLiteral(f.text.String()), kind: Literal, datatype: f.datatype}
+		if f.datatype == "" {
+			o.lang = f.lang
+		}
+		p.emit(f.subject, f.predicate, o)
+	case frameCollection:
+		head := term{value: rdfNS + "nil"}
+		var last string
+		for _, item := range f.items {
+			node := p.blank()
+			if last == "" {
+				head = term{value: node, kind: BlankNode}
+			} else {
+				p.emit(last, rdfNS+"rest", term{value: node, kind: BlankNode})
+			}
+			p.emit(node, rdfNS+"first", item)
+			last = node
+		}
+		if last != "" {
+			p.emit(last, rdfNS+"rest", term{value: rdfNS + "nil"})
+		}
+		p.emit(f.subject, f.predicate, head)
+	}
+}
@@ -0,0 +1,91 @@
+package ntto
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const rdfxmlHeader = `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+         xmlns:foaf="http://xmlns.com/foaf/0.1/"
+         xmlns:gnd="http://d-nb.info/standards/elementset/gnd#"
+         xml:base="http://d-nb.info/gnd/">
+`
+
+var RDFXMLParserTests = []struct {
+	in  string
+	out []string
+}{
+	{`<rdf:Description rdf:about="1"><foaf:name>Alice</foaf:name></rdf:Description>`,
+		[]string{`<http://d-nb.info/gnd/1> <http://xmlns.com/foaf/0.1/name> "Alice" .`}},
+	{`<foaf:Person rdf:about="http://a" foaf:nick="al" xml:lang="en">
+        <foaf:name>Alice "A"</foaf:name>
+        <foaf:name xml:lang="de">Alicia</foaf:name>
+        <foaf:age rdf:datatype="http://www.w3.org/2001/XMLSchema#integer">42</foaf:age>
+        <foaf:knows rdf:resource="2"/>
+        <foaf:knows rdf:nodeID="x"/>
+      </foaf:Person>`,
+		[]string{`<http://a> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://xmlns.com/foaf/0.1/Person> .`,
+			`<http://a> <http://xmlns.com/foaf/0.1/nick> "al"@en .`,
+			`<http://a> <http://xmlns.com/foaf/0.1/name> "Alice \"A\""@en .`,
+			`<http://a> <http://xmlns.com/foaf/0.1/name> "Alicia"@de .`,
+			`<http://a> <http://xmlns.com/foaf/0.1/age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+			`<http://a> <http://xmlns.com/foaf/0.1/knows> <http://d-nb.info/gnd/2> .`,
+			`<http://a> <http://xmlns.com/foaf/0.1/knows> _:x .`}},
+	{`<rdf:Description rdf:about="1">
+        <foaf:knows><foaf:Person rdf:ID="bob"/></foaf:knows>
+        <gnd:place rdf:parseType="Resource"><foaf:name>Leipzig</foaf:name></gnd:place>
+      </rdf:Description>`,
+		[]string{`<http://d-nb.info/gnd/1> <http://xmlns.com/foaf/0.1/knows> <http://d-nb.info/gnd/#bob> .`,
+			`<http://d-nb.info/gnd/#bob> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://xmlns.com/foaf/0.1/Person> .`,
+			`<http://d-nb.info/gnd/1> <http://d-nb.info/standards/elementset/gnd#place> _:genid1 .`,
+			`_:genid1 <http://xmlns.com/foaf/0.1/name> "Leipzig" .`}},
+	{`<rdf:Description rdf:about="1">
+        <gnd:note rdf:parseType="Literal"><b>bold</b> text</gnd:note>
+      </rdf:Description>`,
+		[]string{`<http://d-nb.info/gnd/1> <http://d-nb.info/standards/elementset/gnd#note> "<b>bold</b> text"^^<http://www.w3.org/1999/02/22-rdf-syntax-ns#XMLLiteral> .`}},
+	{`<rdf:Description rdf:about="1">
+        <gnd:list rdf:parseType="Collection">
+          <rdf:Description rdf:about="a"/>
+          <rdf:Description rdf:about="b"/>
+        </gnd:list>
+        <gnd:empty rdf:parseType="Collection"/>
+      </rdf:Description>`,
+		[]string{`_:genid1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> <http://d-nb.info/gnd/a> .`,
+			`_:genid1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:genid2 .`,
+			`_:genid2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> <http://d-nb.info/gnd/b> .`,
+			`_:genid2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`,
+			`<http://d-nb.info/gnd/1> <http://d-nb.info/standards/elementset/gnd#list> _:genid1 .`,
+			`<http://d-nb.info/gnd/1> <http://d-nb.info/standards/elementset/gnd#empty> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`}},
+	{`<rdf:Description rdf:nodeID="genid1">
+        <gnd:place rdf:parseType="Resource"><foaf:name>Leipzig</foaf:name></gnd:place>
+      </rdf:Description>`,
+		[]string{`_:genid_genid1 <http://d-nb.info/standards/elementset/gnd#place> _:genid1 .`,
+			`_:genid1 <http://xmlns.com/foaf/0.1/name> "Leipzig" .`}},
+	{`<rdf:Bag rdf:about="bag"><rdf:li>a</rdf:li><rdf:li>b</rdf:li></rdf:Bag>`,
+		[]string{`<http://d-nb.info/gnd/bag> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://www.w3.org/1999/02/22-rdf-syntax-ns#Bag> .`,
+			`<http://d-nb.info/gnd/bag> <http://www.w3.org/1999/02/22-rdf-syntax-ns#_1> "a" .`,
+			`<http://d-nb.info/gnd/bag> <http://www.w3.org/1999/02/22-rdf-syntax-ns#_2> "b" .`}},
+}
+
+func TestRDFXMLParser(t *testing.T) {
+	for _, tt := range RDFXMLParserTests {
+		p := NewRDFXMLParser(strings.NewReader(rdfxmlHeader+tt.in+"</rdf:RDF>"), "")
+		var out []string
+		for {
+			triple, err := p.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("RDFXMLParser(%s) failed: %s", tt.in, err)
+			}
+			out = append(out, triple.String())
+		}
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("RDFXMLParser(%s) => %q, want: %q", tt.in, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ntto
+
+import (
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestPacksLint(t *testing.T) {
+	total := 0
+	for _, name := range Packs {
+		s, ok := Pack(name)
+		if !ok {
+			t.Fatalf("missing pack: %s", name)
+		}
+		rules, err := ParseRules(s)
+		if err != nil || len(rules) == 0 {
+			t.Errorf("%s: no rules: %v", name, err)
+		}
+		total += len(LintRules(s, "<NULL>"))
+	}
+	// packs must not conflict with each other
+	problems := LintDefaultRules("<NULL>")
+	if len(problems) != total {
+		t.Errorf("got %d problems in DefaultRules, want %d: %v", len(problems), total, problems)
+	}
+	for _, p := range problems {
+		if p.Severity == SeverityError {
+			t.Errorf("built-in:%s", p)
+		}
+	}
+}
+
+func TestPacksComplete(t *testing.T) {
+	files, err := packFiles.ReadDir("rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed := make(map[string]bool)
+	for _, name := range Packs {
+		listed[name+".rules"] = true
+	}
+	for _, f := range files {
+		if !listed[path.Base(f.Name())] {
+			t.Errorf("pack %s is not listed in Packs", f.Name())
+		}
+	}
+	if len(files) != len(Packs) {
+		t.Errorf("got %d pack files, want %d", len(files), len(Packs))
+	}
+}
+
+// TestDefaultRulesCompatible checks, that the packs contain the rules of
+// DefaultRules before the split into packs, kept in testdata, and that rules
+// with overlapping prefixes keep their order, so abbreviations do not change.
+// Only the wikidata pack adds rules.
+func TestDefaultRulesCompatible(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/default.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := ParseRules(string(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := ParseRules(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wikidata, _ := BuiltinProfile("wikidata")
+	added := make(map[Rule]bool)
+	for _, rule := range wikidata {
+		added[rule] = true
+	}
+	position := make(map[Rule]int)
+	for i, rule := range rules {
+		position[rule] = i
+	}
+	for i, rule := range previous {
+		if _, ok := position[rule]; !ok {
+			t.Errorf("missing rule: %s %s", rule.Shortcut, rule.Prefix)
+			continue
+		}
+		delete(added, rule)
+		for _, other := range previous[i+1:] {
+			if !strings.HasPrefix(rule.Prefix, other.Prefix) && !strings.HasPrefix(other.Prefix, rule.Prefix) {
+				continue
+			}
+			if j, ok := position[other]; ok && j < position[rule] {
+				t.Errorf("%s %s must come before %s %s", rule.Shortcut, rule.Prefix, other.Shortcut, other.Prefix)
+			}
+		}
+	}
+	if len(rules) != len(previous)+len(added) {
+		t.Errorf("got %d rules, want %d old and %d wikidata rules", len(rules), len(previous), len(added))
+	}
+}
@@ -0,0 +1,491 @@
+package ntto
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodySize limits the size of request bodies, if a Server does not
+// set its own limit.
+const DefaultMaxBodySize = 32 << 20
+
+// errBodyTooLarge is returned when reading beyond the request size limit.
+var errBodyTooLarge = errors.New("request body too large")
+
+// Server offers conversion, abbreviation, expansion and validation of
+// N-Triples over HTTP. Request bodies are streamed through the same encoders
+// and abbreviators as files on the command line. Endpoints are:
+//
+//	POST /convert?format=json  convert to json, jsonld, esbulk or nt
+//	POST /abbreviate           abbreviate IRIs
+//	POST /expand               expand abbreviated IRIs
+//	POST /validate             check N-Triples, report problems
+//	GET  /rules                list the rules
+//
+// Without a format parameter, /convert picks the format from the Accept
+// header. Turtle and RDF/XML are accepted as input for /convert, if sent with
+// a text/turtle or application/rdf+xml content type.
+type Server struct {
+	// Rules are used for abbreviation, expansion and JSON-LD contexts.
+	Rules []Rule
+	// Null is the shortcut of rules that remove their prefix.
+	Null string
+	// IndexName is the default index for esbulk output, "ntto" if empty.
+	IndexName string
+	// MaxBodySize is the maximum request body size in bytes,
+	// DefaultMaxBodySize if zero.
+	MaxBodySize int64
+}
+
+// ServeHTTP dispatches a request to its endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handler func(http.ResponseWriter, *http.Request)
+	method := http.MethodPost
+	switch r.URL.Path {
+	case "/convert":
+		handler = s.convert
+	case "/abbreviate":
+		handler = s.abbreviate
+	case "/expand":
+		handler = s.expand
+	case "/validate":
+		handler = s.validate
+	case "/rules":
+		handler, method = s.rules, http.MethodGet
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != method && !(method == http.MethodGet && r.Method == http.MethodHead) {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	handler(w, r)
+}
+
+// convertTypes maps acceptable media types to output formats, in order of
+// preference. The json format is line delimited.
+var convertTypes = []struct {
+	mediaType, format string
+}{
+	{"application/x-ndjson", "json"},
+	{"application/json", "json"},
+	{"application/ld+json", "jsonld"},
+	{"application/n-triples", "nt"},
+}
+
+// formatMediaTypes is the content type of each output format.
+var formatMediaTypes = map[string]string{
+	"json":            "application/x-ndjson",
+	"esbulk":          "application/x-ndjson",
+	"jsonld":          "application/ld+json",
+	"jsonld-expanded": "application/ld+json",
+	"nt":              "application/n-triples",
+	"msgpack":         "application/octet-stream",
+	"cbor":            "application/octet-stream",
+}
+
+func (s *Server) convert(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		var offers []string
+		for _, t := range convertTypes {
+			offers = append(offers, t.mediaType)
+		}
+		mediaType := negotiate(r.Header.Get("Accept"), offers)
+		if mediaType == "" {
+			http.Error(w, "no acceptable output format", http.StatusNotAcceptable)
+			return
+		}
+		for _, t := range convertTypes {
+			if t.mediaType == mediaType {
+				format = t.format
+				break
+			}
+		}
+	}
+	contentType, ok := formatMediaTypes[format]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown output format: %s", format), http.StatusBadRequest)
+		return
+	}
+	grouped, err := boolParam(query, "group")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ignore, err := boolParam(query, "ignore")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	typed, err := boolParam(query, "typed")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var shape *JSONShape
+	if spec := query.Get("shape"); spec != "" {
+		if shape, err = ParseJSONShape(spec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	indexName := query.Get("index")
+	if indexName == "" {
+		indexName = s.IndexName
+	}
+	if indexName == "" {
+		indexName = "ntto"
+	}
+	body, ok := s.body(w, r)
+	if !ok {
+		return
+	}
+	reader := newRequestReader(body, r.Header.Get("Content-Type"), ignore)
+	stream(w, contentType, func(bw *bufio.Writer) error {
+		opts := EncoderOptions{Rules: s.Rules, IndexName: indexName, Grouped: grouped, Shape: shape}
+		if typed {
+			opts.Typed = NewTypedLiterals(s.Rules)
+		}
+		encoder, err := NewEncoder(format, bw, opts)
+		if err != nil {
+			return err
+		}
+		for {
+			t, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(t); err != nil {
+				return err
+			}
+		}
+		return encoder.Flush()
+	})
+}
+
+// lineTypes are the media types of line based N-Triples responses.
+var lineTypes = []string{"application/n-triples", "text/plain"}
+
+func (s *Server) abbreviate(w http.ResponseWriter, r *http.Request) {
+	// an abbreviator counts hits, so it cannot be shared between requests
+	s.mapLines(w, r, NewAbbreviator(s.Rules, s.Null).Abbreviate)
+}
+
+func (s *Server) expand(w http.ResponseWriter, r *http.Request) {
+	s.mapLines(w, r, NewExpander(s.Rules, s.Null).Expand)
+}
+
+// mapLines writes every line of the request body with f applied to it.
+func (s *Server) mapLines(w http.ResponseWriter, r *http.Request, f func(string) string) {
+	contentType := negotiate(r.Header.Get("Accept"), lineTypes)
+	if contentType == "" {
+		http.Error(w, "no acceptable output format", http.StatusNotAcceptable)
+		return
+	}
+	body, ok := s.body(w, r)
+	if !ok {
+		return
+	}
+	stream(w, contentType, func(bw *bufio.Writer) error {
+		reader := bufio.NewReader(body)
+		for {
+			line, err := reader.ReadString('\n')
+			if len(line) > 0 {
+				if _, werr := bw.WriteString(f(strings.TrimSuffix(line, "\n")) + "\n"); werr != nil {
+					return werr
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// validationResult is the JSON response of /validate.
+type validationResult struct {
+	ValidationSummary
+	Problems []Problem `json:"problems"`
+}
+
+func (s *Server) validate(w http.ResponseWriter, r *http.Request) {
+	contentType := negotiate(r.Header.Get("Accept"), []string{"application/json", "application/x-ndjson", "text/plain"})
+	if contentType == "" {
+		http.Error(w, "no acceptable output format", http.StatusNotAcceptable)
+		return
+	}
+	body, ok := s.body(w, r)
+	if !ok {
+		return
+	}
+	stream(w, contentType, func(bw *bufio.Writer) error {
+		encoder := json.NewEncoder(bw)
+		encoder.SetEscapeHTML(false)
+		switch contentType {
+		case "application/json":
+			result := validationResult{Problems: []Problem{}}
+			summary, err := Validate(body, func(p Problem) error {
+				result.Problems = append(result.Problems, p)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			result.ValidationSummary = summary
+			return encoder.Encode(result)
+		case "application/x-ndjson":
+			_, err := Validate(body, func(p Problem) error {
+				return encoder.Encode(p)
+			})
+			return err
+		default:
+			_, err := Validate(body, func(p Problem) error {
+				_, err := bw.WriteString(p.String() + "\n")
+				return err
+			})
+			return err
+		}
+	})
+}
+
+// jsonRule is a rule in /rules JSON responses.
+type jsonRule struct {
+	Shortcut string `json:"shortcut"`
+	Prefix   string `json:"prefix"`
+}
+
+func (s *Server) rules(w http.ResponseWriter, r *http.Request) {
+	contentType := negotiate(r.Header.Get("Accept"), []string{"text/plain", "application/json", "application/ld+json"})
+	if contentType == "" {
+		http.Error(w, "no acceptable output format", http.StatusNotAcceptable)
+		return
+	}
+	stream(w, contentType, func(bw *bufio.Writer) error {
+		encoder := json.NewEncoder(bw)
+		encoder.SetEscapeHTML(false)
+		switch contentType {
+		case "application/json":
+			rules := make([]jsonRule, len(s.Rules))
+			for i, rule := range s.Rules {
+				rules[i] = jsonRule{Shortcut: rule.Shortcut, Prefix: rule.Prefix}
+			}
+			return encoder.Encode(rules)
+		case "application/ld+json":
+			return encoder.Encode(map[string]interface{}{"@context": Context(s.Rules)})
+		default:
+			_, err := bw.WriteString(DumpRules(s.Rules) + "\n")
+			return err
+		}
+	})
+}
+
+// body returns the request body, limited to the maximum size. Requests that
+// announce a larger body are rejected right away.
+func (s *Server) body(w http.ResponseWriter, r *http.Request) (io.Reader, bool) {
+	limit := s.MaxBodySize
+	if limit == 0 {
+		limit = DefaultMaxBodySize
+	}
+	if r.ContentLength > limit {
+		http.Error(w, errBodyTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return &limitedReader{r: r.Body, n: limit}, true
+}
+
+// limitedReader reads at most n bytes and fails with errBodyTooLarge, if
+// there is more.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var b [1]byte
+		for {
+			n, err := l.r.Read(b[:])
+			if n > 0 {
+				return 0, errBodyTooLarge
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// trackingWriter records whether anything has been written to the response.
+type trackingWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+// stream calls fn with a buffered writer for the response body. Errors that
+// occur before the first buffer is sent become a 400 or 413 response. Later,
+// the status cannot be changed anymore and the response is aborted, so
+// clients do not mistake a truncated response for a complete one.
+func stream(w http.ResponseWriter, contentType string, fn func(*bufio.Writer) error) {
+	w.Header().Set("Content-Type", contentType)
+	tw := &trackingWriter{w: w}
+	bw := bufio.NewWriter(tw)
+	err := fn(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		return
+	}
+	if tw.written {
+		panic(http.ErrAbortHandler)
+	}
+	status := http.StatusBadRequest
+	if err == errBodyTooLarge {
+		status = http.StatusRequestEntityTooLarge
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// nTriplesReader reads triples line by line, skipping blank lines and
+// comments. With ignore, unparsable lines are skipped, too.
+type nTriplesReader struct {
+	r      *bufio.Reader
+	ignore bool
+	line   int
+}
+
+// Next returns the next triple, or io.EOF.
+func (n *nTriplesReader) Next() (*Triple, error) {
+	for {
+		line, err := n.r.ReadString('\n')
+		if line != "" {
+			n.line++
+		}
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			t, perr := ParseNTriple(line)
+			if perr == nil {
+				t.Line = n.line
+				return t, nil
+			}
+			if !n.ignore {
+				return nil, perr
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// newRequestReader returns a parser for a request body, chosen by its
+// content type. Anything but Turtle and RDF/XML is read as N-Triples.
+func newRequestReader(r io.Reader, contentType string, ignore bool) TripleReader {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/turtle":
+		return NewTurtleParser(bufio.NewReader(r))
+	case "application/rdf+xml":
+		return NewRDFXMLParser(bufio.NewReader(r), "")
+	}
+	return &nTriplesReader{r: bufio.NewReader(r), ignore: ignore}
+}
+
+// boolParam parses an optional boolean query parameter.
+func boolParam(query url.Values, name string) (bool, error) {
+	values := query[name]
+	if len(values) == 0 || values[0] == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(values[0])
+	if err != nil {
+		return false, fmt.Errorf("invalid value for %s: %s", name, values[0])
+	}
+	return b, nil
+}
+
+// mediaRange is a single entry of an Accept header.
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// match tells how specifically the range matches a media type: 2 for an
+// exact match, 1 for type/*, 0 for */* and -1 for no match.
+func (m mediaRange) match(mediaType string) int {
+	switch {
+	case m.mediaType == mediaType:
+		return 2
+	case m.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(m.mediaType, "/*") && strings.HasPrefix(mediaType, m.mediaType[:len(m.mediaType)-1]):
+		return 1
+	}
+	return -1
+}
+
+// negotiate returns the offered media type the Accept header prefers, or the
+// first offer, if the header is empty. Each offer gets the quality of the
+// most specific range matching it; ties go to the earlier offer. If no offer
+// is acceptable, negotiate returns an empty string.
+func negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		m := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(fields[0])), q: 1}
+		for _, param := range fields[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					m.q = q
+				}
+			}
+		}
+		ranges = append(ranges, m)
+	}
+	var best string
+	var bestQ float64
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, m := range ranges {
+			if s := m.match(offer); s > specificity {
+				q, specificity = m.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
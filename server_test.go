@@ -0,0 +1,144 @@
+package ntto
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var serverRules = []Rule{
+	{Shortcut: "ex", Prefix: "http://example.org/"},
+	{Shortcut: "dcterms", Prefix: "http://purl.org/dc/terms/"},
+}
+
+const serverInput = `<http://example.org/a> <http://purl.org/dc/terms/title> "A" .
+<http://example.org/a> <http://example.org/p> <http://example.org/b> .
+`
+
+var ServerTests = []struct {
+	method      string
+	target      string
+	accept      string
+	contentType string
+	body        string
+	status      int
+	mediaType   string
+	out         string
+}{
+	{"POST", "/convert", "", "", serverInput, 200, "application/x-ndjson",
+		`{"s":"http://example.org/a","p":"http://purl.org/dc/terms/title","o":"A"}
+{"s":"http://example.org/a","p":"http://example.org/p","o":"http://example.org/b"}
+`},
+	{"POST", "/convert?format=nt", "", "", serverInput, 200, "application/n-triples", serverInput},
+	{"POST", "/convert", "application/n-triples;q=0.9, application/ld+json", "", serverInput, 200, "application/ld+json", ""},
+	{"POST", "/convert", "text/*, application/n-triples;q=0.5", "", serverInput, 200, "application/n-triples", serverInput},
+	{"POST", "/convert", "text/html", "", serverInput, 406, "text/plain", ""},
+	{"POST", "/convert?format=xml", "", "", serverInput, 400, "text/plain", ""},
+	{"POST", "/convert?format=nt", "", "text/turtle; charset=utf-8",
+		`@prefix ex: <http://example.org/> . ex:a ex:p ex:b .`, 200, "application/n-triples",
+		"<http://example.org/a> <http://example.org/p> <http://example.org/b> .\n"},
+	{"POST", "/convert", "", "", "broken\n", 400, "text/plain", ""},
+	{"POST", "/convert?ignore=true&format=nt", "", "", "broken\n" + serverInput, 200, "application/n-triples", serverInput},
+	{"POST", "/abbreviate", "", "", serverInput, 200, "application/n-triples",
+		`<ex:a> <dcterms:title> "A" .
+<ex:a> <ex:p> <ex:b> .
+`},
+	{"POST", "/expand", "text/plain", "", "<ex:a> <ex:p> \"ex:b\" .\n", 200, "text/plain",
+		"<http://example.org/a> <http://example.org/p> \"ex:b\" .\n"},
+	{"POST", "/validate", "", "", "<a> <http://example.org/p> \"x\" .\n", 200, "application/json",
+		`{"lines":1,"errors":1,"warnings":0,"problems":[{"line":1,"column":1,"severity":"error","message":"IRI <a> is not absolute"}]}
+`},
+	{"POST", "/validate", "", "", serverInput, 200, "application/json",
+		`{"lines":2,"errors":0,"warnings":0,"problems":[]}
+`},
+	{"GET", "/rules", "application/json", "", "", 200, "application/json",
+		`[{"shortcut":"ex","prefix":"http://example.org/"},{"shortcut":"dcterms","prefix":"http://purl.org/dc/terms/"}]
+`},
+	{"GET", "/rules", "application/ld+json", "", "", 200, "application/ld+json",
+		`{"@context":{"dcterms":"http://purl.org/dc/terms/","ex":"http://example.org/"}}
+`},
+	{"GET", "/convert", "", "", "", 405, "text/plain", ""},
+	{"POST", "/rules", "", "", "", 405, "text/plain", ""},
+	{"GET", "/", "", "", "", 404, "text/plain", ""},
+}
+
+func TestServer(t *testing.T) {
+	server := &Server{Rules: serverRules, Null: "<NULL>"}
+	for _, tt := range ServerTests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: got status %d, want %d: %s", tt.method, tt.target, rec.Code, tt.status, rec.Body.String())
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.mediaType) {
+			t.Errorf("%s %s: got content type %s, want %s", tt.method, tt.target, got, tt.mediaType)
+		}
+		if tt.out != "" && rec.Body.String() != tt.out {
+			t.Errorf("%s %s: got %q, want %q", tt.method, tt.target, rec.Body.String(), tt.out)
+		}
+	}
+}
+
+func TestServerMaxBodySize(t *testing.T) {
+	ts := httptest.NewServer(&Server{Rules: serverRules, MaxBodySize: 100})
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/abbreviate", "application/n-triples", strings.NewReader(serverInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusRequestEntityTooLarge)
+	}
+
+	// without a content length, the limit applies while reading
+	req := httptest.NewRequest("POST", "/abbreviate", ioutil.NopCloser(strings.NewReader(serverInput)))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	(&Server{Rules: serverRules, MaxBodySize: 100}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got status %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	resp, err = http.Post(ts.URL+"/abbreviate", "application/n-triples", strings.NewReader(serverInput[:90]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+var NegotiateTests = []struct {
+	accept string
+	offers []string
+	out    string
+}{
+	{"", []string{"a/b", "c/d"}, "a/b"},
+	{"c/d", []string{"a/b", "c/d"}, "c/d"},
+	{"*/*", []string{"a/b", "c/d"}, "a/b"},
+	{"a/b;q=0.5, c/*", []string{"a/b", "c/d"}, "c/d"},
+	{"*/*;q=0.1, a/b;q=0", []string{"a/b", "c/d"}, "c/d"},
+	{"x/y", []string{"a/b", "c/d"}, ""},
+	{"A/B", []string{"a/b"}, "a/b"},
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, tt := range NegotiateTests {
+		if got := negotiate(tt.accept, tt.offers); got != tt.out {
+			t.Errorf("negotiate(%q, %v): got %q, want %q", tt.accept, tt.offers, got, tt.out)
+		}
+	}
+}
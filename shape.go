@@ -0,0 +1,186 @@
+package ntto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Fields of a triple a JSON shape can refer to, as $s, $p and so on.
+const (
+	ShapeSubject   = "s"
+	ShapePredicate = "p"
+	ShapeObject    = "o"
+	ShapeLang      = "lang"
+	ShapeDatatype  = "datatype"
+	ShapeGraph     = "graph"
+	ShapeLine      = "line"
+)
+
+var shapeFields = map[string]bool{
+	ShapeSubject: true, ShapePredicate: true, ShapeObject: true, ShapeLang: true,
+	ShapeDatatype: true, ShapeGraph: true, ShapeLine: true,
+}
+
+// builtinShapes are the named templates for ParseJSONShape.
+var builtinShapes = map[string]string{
+	"default": `{"s": "$s", "p": "$p", "o": "$o"}`,
+	"long":    `{"subject": "$s", "predicate": "$p", "object": "$o", "lang": "$lang", "datatype": "$datatype"}`,
+	"array":   `["$s", "$p", "$o"]`,
+}
+
+// quadShape is the JSON form of a quad without shape, like the JSON encoding
+// of Quad.
+var quadShape = &JSONShape{Fields: []ShapeField{
+	{Key: "s", Field: ShapeSubject},
+	{Key: "p", Field: ShapePredicate},
+	{Key: "o", Field: ShapeObject},
+	{Key: "g", Field: ShapeGraph},
+}}
+
+// BuiltinShapes returns the names of the built-in JSON shapes, sorted.
+func BuiltinShapes() []string {
+	var names []string
+	for name := range builtinShapes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ShapeField is a field of a triple written under a key.
+type ShapeField struct {
+	Key   string
+	Field string
+}
+
+// JSONShape is the layout of the JSON document written per triple: an object
+// with the fields under the given keys, in order, or an array of the fields,
+// if Array is set, in which case keys are ignored. In objects, empty
+// language tags, datatypes and graphs and unknown line numbers are left out,
+// in arrays they are null, so positions stay fixed.
+type JSONShape struct {
+	Fields []ShapeField
+	Array  bool
+}
+
+// ParseJSONShape parses a shape template: a JSON object whose values name a
+// field, like {"subject": "$s", "object": "$o", "lang": "$lang"}, or a JSON
+// array of field names, like ["$s", "$p", "$o"]. The name of a built-in
+// shape is accepted, too.
+func ParseJSONShape(s string) (*JSONShape, error) {
+	if t, ok := builtinShapes[strings.TrimSpace(s)]; ok {
+		s = t
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("broken shape: %v", err)
+	}
+	shape := &JSONShape{}
+	switch tok {
+	case json.Delim('{'):
+	case json.Delim('['):
+		shape.Array = true
+	default:
+		return nil, fmt.Errorf("shape must be a JSON object or array")
+	}
+	keys := make(map[string]bool)
+	for dec.More() {
+		var key string
+		if !shape.Array {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("broken shape: %v", err)
+			}
+			key = tok.(string)
+			if keys[key] {
+				return nil, fmt.Errorf("duplicate key in shape: %s", key)
+			}
+			keys[key] = true
+		}
+		var ref string
+		if err := dec.Decode(&ref); err != nil {
+			return nil, fmt.Errorf("shape values must be strings like \"$s\": %v", err)
+		}
+		field := strings.TrimPrefix(ref, "$")
+		if !strings.HasPrefix(ref, "$") || !shapeFields[field] {
+			return nil, fmt.Errorf("unknown field in shape: %s", ref)
+		}
+		shape.Fields = append(shape.Fields, ShapeField{Key: key, Field: field})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("broken shape: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("broken shape: trailing data")
+	}
+	return shape, nil
+}
+
+// Marshal returns the JSON document of a quad. If typed is not nil, typed
+// literals become native JSON values.
+func (s *JSONShape) Marshal(q *Quad, typed *TypedLiterals) ([]byte, error) {
+	var buf bytes.Buffer
+	if s.Array {
+		buf.WriteByte('[')
+	} else {
+		buf.WriteByte('{')
+	}
+	n := 0
+	for _, f := range s.Fields {
+		var value interface{}
+		switch f.Field {
+		case ShapeSubject:
+			value = q.Subject
+		case ShapePredicate:
+			value = q.Predicate
+		case ShapeObject:
+			value = q.Object
+			if typed != nil {
+				value = typed.Value(&q.Triple)
+			}
+		case ShapeLang:
+			value = q.Lang
+		case ShapeDatatype:
+			value = q.Datatype
+		case ShapeGraph:
+			value = q.Graph
+		case ShapeLine:
+			value = q.Line
+		}
+		optional := f.Field != ShapeSubject && f.Field != ShapePredicate && f.Field != ShapeObject
+		if optional && (value == "" || value == 0) {
+			if !s.Array {
+				continue
+			}
+			value = nil
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		n++
+		if !s.Array {
+			key, err := json.Marshal(f.Key)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(key)
+			buf.WriteByte(':')
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	if s.Array {
+		buf.WriteByte(']')
+	} else {
+		buf.WriteByte('}')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+package ntto
+
+import (
+	"testing"
+)
+
+var JSONShapeTests = []struct {
+	shape string
+	quad  Quad
+	out   string
+}{
+	{"default", Quad{Triple: Triple{Subject: "a", Predicate: "p", Object: "<b>"}}, `{"s":"a","p":"p","o":"\u003cb\u003e"}`},
+	{"long", Quad{Triple: Triple{Subject: "a", Predicate: "p", Object: "b", Lang: "en"}},
+		`{"subject":"a","predicate":"p","object":"b","lang":"en"}`},
+	{"array", Quad{Triple: Triple{Subject: "a", Predicate: "p", Object: "b"}}, `["a","p","b"]`},
+	{`["$s", "$lang", "$datatype", "$line"]`, Quad{Triple: Triple{Subject: "a", Predicate: "p", Object: "b", Lang: "de", Line: 7}},
+		`["a","de",null,7]`},
+	{`{"id": "$s", "g": "$graph", "n": "$line", "o": "$o"}`, Quad{Triple: Triple{Subject: "a", Predicate: "p", Object: ""}, Graph: "g"},
+		`{"id":"a","g":"g","o":""}`},
+	{`{"ö\"": "$s"}`, Quad{Triple: Triple{Subject: "a"}}, `{"ö\"":"a"}`},
+}
+
+func TestJSONShape(t *testing.T) {
+	for _, tt := range JSONShapeTests {
+		shape, err := ParseJSONShape(tt.shape)
+		if err != nil {
+			t.Fatalf("ParseJSONShape(%s): %v", tt.shape, err)
+		}
+		b, err := shape.Marshal(&tt.quad, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != tt.out {
+			t.Errorf("%s: got %s, want %s", tt.shape, b, tt.out)
+		}
+	}
+}
+
+func TestParseJSONShapeErrors(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"unknown",
+		`"$s"`,
+		`{"s": "$x"}`,
+		`{"s": "s"}`,
+		`{"s": 1}`,
+		`{"s": "$s", "s": "$p"}`,
+		`["$s"] []`,
+		`["$s"`,
+	} {
+		if _, err := ParseJSONShape(s); err == nil {
+			t.Errorf("ParseJSONShape(%q): expected error", s)
+		}
+	}
+}
+
+func TestJSONEncoderShape(t *testing.T) {
+	triple := &Triple{Subject: "a", Predicate: "p", Object: "b"}
+	b1, err := marshalTriple(triple, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape, err := ParseJSONShape("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := shape.Marshal(&Quad{Triple: *triple}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b1) != string(b2) {
+		t.Errorf("default shape: got %s, want %s", b2, b1)
+	}
+	if _, err := NewEncoder("esbulk", nil, EncoderOptions{Shape: shape}); err == nil {
+		t.Errorf("expected error for esbulk with shape")
+	}
+}
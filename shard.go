@@ -0,0 +1,136 @@
+package ntto
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Sharder assigns a triple to a shard. The key is used in file names.
+type Sharder func(t *Triple) string
+
+// SubjectHashSharder distributes subjects over n shards by hash, so all
+// triples of a subject end up in the same shard. Keys are zero-padded
+// numbers.
+func SubjectHashSharder(n int) Sharder {
+	if n < 1 {
+		n = 1
+	}
+	width := len(strconv.Itoa(n - 1))
+	return func(t *Triple) string {
+		return fmt.Sprintf("%0*d", width, hash64(t.Subject)%uint64(n))
+	}
+}
+
+// unsafeFileChars matches characters that should not appear in file names.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// shardKey makes a string usable as part of a file name. If characters had to
+// be replaced, a short hash of s is appended, so different strings never
+// share a key, e.g. p?q=1 and p_q=1.
+func shardKey(s string) string {
+	key := unsafeFileChars.ReplaceAllString(s, "_")
+	if key == s {
+		return key
+	}
+	return fmt.Sprintf("%s-%08x", key, uint32(hash64(s)))
+}
+
+// PredicateSharder puts each predicate into its own shard. Predicates that
+// match a rule are named by their abbreviation, e.g. dcterms_title.
+func PredicateSharder(rules []Rule) Sharder {
+	index := newPrefixIndex(rules)
+	return func(t *Triple) string {
+		return shardKey(index.compact(t.Predicate))
+	}
+}
+
+// otherShard is the shard of subjects no rule matches.
+const otherShard = "_other"
+
+// NamespaceSharder shards by the rule that matches the subject, keyed by its
+// shortcut. Subjects no rule matches go into the shard named _other; a
+// shortcut _other gets a hash appended, like keys with replaced characters.
+func NamespaceSharder(rules []Rule) Sharder {
+	index := newPrefixIndex(rules)
+	return func(t *Triple) string {
+		rule, ok := index.match(t.Subject)
+		if !ok {
+			return otherShard
+		}
+		if key := shardKey(rule.Shortcut); key != otherShard {
+			return key
+		}
+		return fmt.Sprintf("%s-%08x", otherShard, uint32(hash64(rule.Shortcut)))
+	}
+}
+
+// ShardEncoder writes triples to one encoder per shard. Encoders are created
+// on first use of a shard, so any output format works per shard.
+type ShardEncoder struct {
+	sharder Sharder
+	create  func(key string) (Encoder, io.Closer, error)
+	shards  map[string]*shard
+}
+
+type shard struct {
+	encoder Encoder
+	closer  io.Closer
+}
+
+// NewShardEncoder returns an encoder that splits triples with sharder and
+// calls create for each new shard. The closer is closed by Close, after the
+// encoder has been flushed.
+func NewShardEncoder(sharder Sharder, create func(key string) (Encoder, io.Closer, error)) *ShardEncoder {
+	return &ShardEncoder{sharder: sharder, create: create, shards: make(map[string]*shard)}
+}
+
+// Encode writes a triple to its shard.
+func (e *ShardEncoder) Encode(t *Triple) error {
+	key := e.sharder(t)
+	s, ok := e.shards[key]
+	if !ok {
+		encoder, closer, err := e.create(key)
+		if err != nil {
+			return err
+		}
+		s = &shard{encoder: encoder, closer: closer}
+		e.shards[key] = s
+	}
+	return s.encoder.Encode(t)
+}
+
+// Keys returns the shards written so far, sorted.
+func (e *ShardEncoder) Keys() []string {
+	var keys []string
+	for key := range e.shards {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Flush flushes the encoders of all shards.
+func (e *ShardEncoder) Flush() error {
+	for _, key := range e.Keys() {
+		if err := e.shards[key].encoder.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all shards. It does not flush, call Flush first.
+func (e *ShardEncoder) Close() error {
+	var first error
+	for _, key := range e.Keys() {
+		if c := e.shards[key].closer; c != nil {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
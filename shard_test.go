@@ -0,0 +1,140 @@
+package ntto
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type closeRecorder struct {
+	closed *[]string
+	key    string
+}
+
+func (c closeRecorder) Close() error {
+	*c.closed = append(*c.closed, c.key)
+	return nil
+}
+
+var shardTriples = []*Triple{
+	{Subject: "http://d-nb.info/gnd/1", Predicate: "http://purl.org/dc/terms/title", Object: "a", ObjectKind: Literal},
+	{Subject: "http://viaf.org/viaf/2", Predicate: "http://purl.org/dc/terms/title", Object: "b", ObjectKind: Literal},
+	{Subject: "http://d-nb.info/gnd/1", Predicate: "http://ex.org/p#q", Object: "http://viaf.org/viaf/2"},
+	{Subject: "http://ex.org/3", Predicate: "http://ex.org/p#q", Object: "c", ObjectKind: Literal},
+}
+
+func runShards(t *testing.T, sharder Sharder) (map[string]string, []string) {
+	buffers := make(map[string]*bytes.Buffer)
+	var closed []string
+	e := NewShardEncoder(sharder, func(key string) (Encoder, io.Closer, error) {
+		buffers[key] = &bytes.Buffer{}
+		return NewNTriplesEncoder(buffers[key]), closeRecorder{&closed, key}, nil
+	})
+	for _, triple := range shardTriples {
+		if err := e.Encode(triple); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]string)
+	for key, buf := range buffers {
+		out[key] = buf.String()
+	}
+	return out, closed
+}
+
+func TestShardEncoderPredicate(t *testing.T) {
+	rules, err := ParseRules(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, closed := runShards(t, PredicateSharder(rules))
+	want := map[string]string{
+		"dcterms_title-9da384f7":   shardTriples[0].String() + "\n" + shardTriples[1].String() + "\n",
+		"http_ex.org_p_q-d160c056": shardTriples[2].String() + "\n" + shardTriples[3].String() + "\n",
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("PredicateSharder => %q, want: %q", out, want)
+	}
+	if !reflect.DeepEqual(closed, []string{"dcterms_title-9da384f7", "http_ex.org_p_q-d160c056"}) {
+		t.Errorf("Close => closed %v", closed)
+	}
+}
+
+func TestShardEncoderNamespace(t *testing.T) {
+	rules, err := ParseRules(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := runShards(t, NamespaceSharder(rules))
+	want := map[string]string{
+		"gnd":    shardTriples[0].String() + "\n" + shardTriples[2].String() + "\n",
+		"viaf":   shardTriples[1].String() + "\n",
+		"_other": shardTriples[3].String() + "\n",
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("NamespaceSharder => %q, want: %q", out, want)
+	}
+	// shortcuts must not share a shard with subjects no rule matches
+	sharder := NamespaceSharder([]Rule{{Shortcut: "other", Prefix: "http://o/"}, {Shortcut: "_other", Prefix: "http://u/"}})
+	keys := make(map[string]bool)
+	for _, s := range []string{"http://o/a", "http://u/a", "http://x/a"} {
+		keys[sharder(&Triple{Subject: s})] = true
+	}
+	if len(keys) != 3 {
+		t.Errorf("NamespaceSharder => keys %v, want three", keys)
+	}
+}
+
+func TestSubjectHashSharder(t *testing.T) {
+	sharder := SubjectHashSharder(12)
+	seen := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		for _, triple := range shardTriples {
+			key := sharder(triple)
+			if len(key) != 2 || key > "11" {
+				t.Fatalf("SubjectHashSharder(12) => %q", key)
+			}
+			if k, ok := seen[triple.Subject]; ok && k != key {
+				t.Fatalf("SubjectHashSharder(12) => %q and %q for %s", k, key, triple.Subject)
+			}
+			seen[triple.Subject] = key
+		}
+	}
+}
+
+var ShardKeyTests = []struct {
+	in  string
+	out string
+}{
+	{"gnd", "gnd"},
+	{"a-b_c.d", "a-b_c.d"},
+	{"http://x.org/p?q=1", "http_x.org_p_q_1-%08x"},
+	{"http://x.org/p_q=1", "http_x.org_p_q_1-%08x"},
+}
+
+func TestShardKey(t *testing.T) {
+	seen := make(map[string]string)
+	for _, tt := range ShardKeyTests {
+		want := tt.out
+		if strings.Contains(want, "%") {
+			want = fmt.Sprintf(want, uint32(hash64(tt.in)))
+		}
+		key := shardKey(tt.in)
+		if key != want {
+			t.Errorf("shardKey(%s) => %s, want: %s", tt.in, key, want)
+		}
+		if s, ok := seen[key]; ok {
+			t.Errorf("shardKey(%s) and shardKey(%s) => %s", s, tt.in, key)
+		}
+		seen[key] = tt.in
+	}
+}
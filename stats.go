@@ -0,0 +1,185 @@
+package ntto
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Namespace returns the part of an IRI up to and including the last slash or
+// hash, but never just the scheme. Blank nodes all share the namespace "_:".
+func Namespace(iri string) string {
+	if strings.HasPrefix(iri, "_:") {
+		return "_:"
+	}
+	if i := strings.LastIndexAny(iri, "/#"); i >= 0 && !strings.HasSuffix(iri[:i+1], "//") {
+		return iri[:i+1]
+	}
+	return iri
+}
+
+// RuleSavings is the estimated effect of a single rule.
+type RuleSavings struct {
+	Rule  Rule  `json:"-"`
+	Hits  int64 `json:"hits"`
+	Saved int64 `json:"saved"`
+}
+
+// Stats collects counts over a stream of triples in a single pass. Memory is
+// bounded, each counter keeps at most a fixed number of keys. Stats
+// implements Encoder, so it can sit at the end of a pipeline.
+type Stats struct {
+	Triples           int64
+	Predicates        *Counter
+	SubjectNamespaces *Counter
+	ObjectKinds       map[Kind]int64
+	Languages         *Counter
+	Datatypes         *Counter
+	Rules             []RuleSavings
+	index             *prefixIndex
+	rules             map[string]int
+}
+
+// NewStats returns empty statistics. Counters keep at most max keys and byte
+// savings are estimated for the given rules.
+func NewStats(rules []Rule, max int) *Stats {
+	s := &Stats{
+		Predicates:        NewCounter(max),
+		SubjectNamespaces: NewCounter(max),
+		ObjectKinds:       make(map[Kind]int64),
+		Languages:         NewCounter(max),
+		Datatypes:         NewCounter(max),
+		index:             newPrefixIndex(curieRules(rules)),
+		rules:             make(map[string]int),
+	}
+	for _, rule := range rules {
+		if _, ok := s.rules[rule.Prefix]; ok {
+			continue
+		}
+		s.rules[rule.Prefix] = len(s.Rules)
+		s.Rules = append(s.Rules, RuleSavings{Rule: rule})
+	}
+	return s
+}
+
+// saving records the savings of abbreviating an IRI.
+func (s *Stats) saving(iri string) {
+	rule, ok := s.index.match(iri)
+	if !ok {
+		return
+	}
+	r := &s.Rules[s.rules[rule.Prefix]]
+	r.Hits++
+	r.Saved += int64(len(rule.Prefix) - len(rule.Shortcut) - 1)
+}
+
+// Encode adds a triple to the statistics.
+func (s *Stats) Encode(t *Triple) error {
+	s.Triples++
+	s.Predicates.Add(t.Predicate, 1)
+	s.SubjectNamespaces.Add(Namespace(t.Subject), 1)
+	s.ObjectKinds[t.ObjectKind]++
+	s.saving(t.Subject)
+	s.saving(t.Predicate)
+	switch t.ObjectKind {
+	case IRI:
+		s.saving(t.Object)
+	case Literal:
+		if t.Lang != "" {
+			s.Languages.Add(t.Lang, 1)
+		}
+		if t.Datatype != "" {
+			s.Datatypes.Add(t.Datatype, 1)
+			s.saving(t.Datatype)
+		}
+	}
+	return nil
+}
+
+// Flush is a no-op.
+func (s *Stats) Flush() error {
+	return nil
+}
+
+// StatsReport is a summary of Stats, suitable for JSON output.
+type StatsReport struct {
+	Triples           int64            `json:"triples"`
+	Predicates        []Count          `json:"predicates"`
+	SubjectNamespaces []Count          `json:"subject_namespaces"`
+	ObjectKinds       map[string]int64 `json:"object_kinds"`
+	Languages         []Count          `json:"languages"`
+	Datatypes         []Count          `json:"datatypes"`
+	Rules             []RuleReport     `json:"rules"`
+}
+
+// RuleReport are the savings of a rule, for JSON output.
+type RuleReport struct {
+	Shortcut string `json:"shortcut"`
+	Prefix   string `json:"prefix"`
+	Hits     int64  `json:"hits"`
+	Saved    int64  `json:"saved"`
+}
+
+// Report returns the top n entries of each counter and the rules that
+// matched, ordered by bytes saved.
+func (s *Stats) Report(n int) StatsReport {
+	r := StatsReport{
+		Triples:           s.Triples,
+		Predicates:        s.Predicates.Top(n),
+		SubjectNamespaces: s.SubjectNamespaces.Top(n),
+		ObjectKinds: map[string]int64{
+			"iri":     s.ObjectKinds[IRI],
+			"literal": s.ObjectKinds[Literal],
+			"bnode":   s.ObjectKinds[BlankNode],
+		},
+		Languages: s.Languages.Top(n),
+		Datatypes: s.Datatypes.Top(n),
+		Rules:     []RuleReport{},
+	}
+	for _, rs := range s.Rules {
+		if rs.Hits == 0 {
+			continue
+		}
+		r.Rules = append(r.Rules, RuleReport{
+			Shortcut: rs.Rule.Shortcut,
+			Prefix:   rs.Rule.Prefix,
+			Hits:     rs.Hits,
+			Saved:    rs.Saved,
+		})
+	}
+	sort.SliceStable(r.Rules, func(i, j int) bool { return r.Rules[i].Saved > r.Rules[j].Saved })
+	if n > 0 && n < len(r.Rules) {
+		r.Rules = r.Rules[:n]
+	}
+	return r
+}
+
+// WriteTable writes the report as plain text tables.
+func (r StatsReport) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "triples\t%d\n", r.Triples)
+	for _, kind := range []string{"iri", "literal", "bnode"} {
+		fmt.Fprintf(tw, "objects (%s)\t%d\n", kind, r.ObjectKinds[kind])
+	}
+	for _, section := range []struct {
+		title  string
+		counts []Count
+	}{
+		{"predicate", r.Predicates},
+		{"subject namespace", r.SubjectNamespaces},
+		{"language", r.Languages},
+		{"datatype", r.Datatypes},
+	} {
+		fmt.Fprintf(tw, "\n%s\tcount\n", section.title)
+		for _, c := range section.counts {
+			fmt.Fprintf(tw, "%s\t%d\n", c.Key, c.Count)
+		}
+	}
+	fmt.Fprintf(tw, "\nrule\tprefix\thits\tsaved\n")
+	for _, rr := range r.Rules {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", rr.Shortcut, rr.Prefix, rr.Hits, rr.Saved)
+	}
+	return tw.Flush()
+}
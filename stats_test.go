@@ -0,0 +1,65 @@
+package ntto
+
+import (
+	"reflect"
+	"testing"
+)
+
+var NamespaceTests = []struct {
+	in  string
+	out string
+}{
+	{"http://d-nb.info/gnd/118540238", "http://d-nb.info/gnd/"},
+	{"http://www.w3.org/2000/01/rdf-schema#label", "http://www.w3.org/2000/01/rdf-schema#"},
+	{"_:b0", "_:"},
+	{"urn:isbn:123", "urn:isbn:123"},
+	{"http://example.org", "http://example.org"},
+}
+
+func TestNamespace(t *testing.T) {
+	for _, tt := range NamespaceTests {
+		if out := Namespace(tt.in); out != tt.out {
+			t.Errorf("Namespace(%s) => %s, want: %s", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	rules := []Rule{
+		Rule{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/"},
+		Rule{Shortcut: "rdfs", Prefix: "http://www.w3.org/2000/01/rdf-schema#"},
+		Rule{Shortcut: "foaf", Prefix: "http://xmlns.com/foaf/0.1/"},
+	}
+	triples := []Triple{
+		Triple{Subject: "http://d-nb.info/gnd/1", Predicate: "http://www.w3.org/2000/01/rdf-schema#label",
+			Object: "Goethe", ObjectKind: Literal, Lang: "de"},
+		Triple{Subject: "http://d-nb.info/gnd/1", Predicate: "http://www.w3.org/2000/01/rdf-schema#seeAlso",
+			Object: "http://d-nb.info/gnd/2"},
+		Triple{Subject: "_:b0", Predicate: "http://example.org/age",
+			Object: "1", ObjectKind: Literal, Datatype: "http://www.w3.org/2001/XMLSchema#integer"},
+	}
+	s := NewStats(rules, 100)
+	for i := range triples {
+		s.Encode(&triples[i])
+	}
+	r := s.Report(0)
+	want := StatsReport{
+		Triples: 3,
+		Predicates: []Count{
+			{Key: "http://example.org/age", Count: 1},
+			{Key: "http://www.w3.org/2000/01/rdf-schema#label", Count: 1},
+			{Key: "http://www.w3.org/2000/01/rdf-schema#seeAlso", Count: 1},
+		},
+		SubjectNamespaces: []Count{{Key: "http://d-nb.info/gnd/", Count: 2}, {Key: "_:", Count: 1}},
+		ObjectKinds:       map[string]int64{"iri": 1, "literal": 2, "bnode": 0},
+		Languages:         []Count{{Key: "de", Count: 1}},
+		Datatypes:         []Count{{Key: "http://www.w3.org/2001/XMLSchema#integer", Count: 1}},
+		Rules: []RuleReport{
+			{Shortcut: "rdfs", Prefix: "http://www.w3.org/2000/01/rdf-schema#", Hits: 2, Saved: 64},
+			{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/", Hits: 3, Saved: 51},
+		},
+	}
+	if !reflect.DeepEqual(r, want) {
+		t.Errorf("Stats.Report(0) => %+v, want: %+v", r, want)
+	}
+}
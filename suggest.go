@@ -0,0 +1,168 @@
+package ntto
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Suggestion is a suggested rule with the number of IRIs it would shorten and
+// the bytes it would save.
+type Suggestion struct {
+	Rule
+	Hits  int64
+	Saved int64
+}
+
+// String formats the suggestion as a line of a rules file.
+func (s Suggestion) String() string {
+	return fmt.Sprintf("%s\t%s\t# %d hits, %d bytes saved", s.Shortcut, s.Prefix, s.Hits, s.Saved)
+}
+
+// RuleSuggester counts IRI namespaces of a stream of triples and suggests rules
+// for the frequent ones. It implements Encoder.
+type RuleSuggester struct {
+	namespaces *Counter
+	known      map[string]string
+	shortcuts  map[string]bool
+}
+
+// NewRuleSuggester returns a suggester that reuses the shortcuts of known rules
+// for matching prefixes and keeps at most max namespaces.
+func NewRuleSuggester(known []Rule, max int) *RuleSuggester {
+	s := &RuleSuggester{
+		namespaces: NewCounter(max),
+		known:      make(map[string]string),
+		shortcuts:  make(map[string]bool),
+	}
+	for _, rule := range known {
+		if _, ok := s.known[rule.Prefix]; !ok {
+			s.known[rule.Prefix] = rule.Shortcut
+		}
+		s.shortcuts[rule.Shortcut] = true
+	}
+	return s
+}
+
+func (s *RuleSuggester) add(iri string) {
+	if strings.HasPrefix(iri, "_:") {
+		return
+	}
+	if ns := Namespace(iri); ns != iri {
+		s.namespaces.Add(ns, 1)
+	}
+}
+
+// Encode counts the namespaces of all IRIs of a triple.
+func (s *RuleSuggester) Encode(t *Triple) error {
+	s.add(t.Subject)
+	s.add(t.Predicate)
+	switch t.ObjectKind {
+	case IRI:
+		s.add(t.Object)
+	case Literal:
+		if t.Datatype != "" {
+			s.add(t.Datatype)
+		}
+	}
+	return nil
+}
+
+// Flush is a no-op.
+func (s *RuleSuggester) Flush() error {
+	return nil
+}
+
+// Suggest returns at most n rules, zero for no limit, for namespaces seen at
+// least min times, ranked by bytes saved.
+func (s *RuleSuggester) Suggest(n int, min int64) []Suggestion {
+	var suggestions []Suggestion
+	for _, c := range s.namespaces.Top(0) {
+		if c.Count < min {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{Rule: Rule{Prefix: c.Key, Shortcut: s.known[c.Key]}, Hits: c.Count})
+	}
+	// rank with a provisional shortcut length, so new shortcuts go to the most
+	// valuable prefixes first
+	for i := range suggestions {
+		suggestions[i].Saved = saved(suggestions[i].Rule, suggestions[i].Hits)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Saved > suggestions[j].Saved })
+	taken := make(map[string]bool)
+	for k := range s.shortcuts {
+		taken[k] = true
+	}
+	var result []Suggestion
+	for _, sg := range suggestions {
+		if sg.Shortcut == "" {
+			sg.Shortcut = uniqueShortcut(ShortcutFor(sg.Prefix), taken)
+		}
+		taken[sg.Shortcut] = true
+		sg.Saved = saved(sg.Rule, sg.Hits)
+		if sg.Saved <= 0 {
+			continue
+		}
+		result = append(result, sg)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Saved > result[j].Saved })
+	if n > 0 && n < len(result) {
+		result = result[:n]
+	}
+	return result
+}
+
+func saved(rule Rule, hits int64) int64 {
+	return hits * int64(len(rule.Prefix)-len(rule.Shortcut)-1)
+}
+
+var nonAlnum = regexp.MustCompile(`[^a-z0-9]+`)
+
+// ShortcutFor derives a shortcut from a prefix: the last path segment, or the
+// host name without top level domain and "www", if there is no path.
+func ShortcutFor(prefix string) string {
+	var candidates []string
+	if u, err := url.Parse(prefix); err == nil && u.Host != "" {
+		segments := strings.Split(strings.Trim(u.Path+u.Fragment, "/#"), "/")
+		for i := len(segments) - 1; i >= 0; i-- {
+			candidates = append(candidates, segments[i])
+		}
+		labels := strings.Split(u.Hostname(), ".")
+		if len(labels) > 1 {
+			labels = labels[:len(labels)-1]
+		}
+		for i := len(labels) - 1; i >= 0; i-- {
+			if labels[i] != "www" {
+				candidates = append(candidates, labels[i])
+			}
+		}
+	} else {
+		candidates = strings.FieldsFunc(prefix, func(r rune) bool { return r == ':' || r == '/' || r == '#' })
+		for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+	}
+	for _, c := range candidates {
+		c = nonAlnum.ReplaceAllString(strings.ToLower(c), "")
+		c = strings.TrimLeft(c, "0123456789")
+		if len(c) > 8 {
+			c = c[:8]
+		}
+		if c != "" {
+			return c
+		}
+	}
+	return "ns"
+}
+
+// uniqueShortcut appends a number to the shortcut, if it is already taken.
+func uniqueShortcut(shortcut string, taken map[string]bool) string {
+	candidate := shortcut
+	for i := 2; taken[candidate]; i++ {
+		candidate = shortcut + strconv.Itoa(i)
+	}
+	return candidate
+}
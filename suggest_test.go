@@ -0,0 +1,57 @@
+package ntto
+
+import (
+	"reflect"
+	"testing"
+)
+
+var ShortcutForTests = []struct {
+	in  string
+	out string
+}{
+	{"http://d-nb.info/standards/elementset/gnd#", "gnd"},
+	{"http://example.org/", "example"},
+	{"http://www.example.org/", "example"},
+	{"http://example.org/ontology/", "ontology"},
+	{"http://example.org/2014/", "example"},
+	{"http://example.org/VeryLongSegmentName/", "verylong"},
+	{"urn:isbn:", "isbn"},
+	{"http://127.0.0.1/", "ns"},
+}
+
+func TestShortcutFor(t *testing.T) {
+	for _, tt := range ShortcutForTests {
+		if out := ShortcutFor(tt.in); out != tt.out {
+			t.Errorf("ShortcutFor(%s) => %s, want: %s", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestRuleSuggester(t *testing.T) {
+	known := []Rule{
+		Rule{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/"},
+		Rule{Shortcut: "example", Prefix: "http://example.com/"},
+	}
+	triples := []Triple{
+		Triple{Subject: "http://d-nb.info/gnd/1", Predicate: "http://example.org/vocab/name", Object: "a", ObjectKind: Literal},
+		Triple{Subject: "http://d-nb.info/gnd/2", Predicate: "http://example.org/vocab/name", Object: "b", ObjectKind: Literal},
+		Triple{Subject: "http://d-nb.info/gnd/3", Predicate: "http://example.org/p", Object: "http://example.org/vocab/x"},
+		Triple{Subject: "_:b0", Predicate: "http://example.org/p", Object: "c", ObjectKind: Literal},
+	}
+	s := NewRuleSuggester(known, 100)
+	for i := range triples {
+		s.Encode(&triples[i])
+	}
+	out := s.Suggest(0, 2)
+	want := []Suggestion{
+		Suggestion{Rule: Rule{Shortcut: "vocab", Prefix: "http://example.org/vocab/"}, Hits: 3, Saved: 57},
+		Suggestion{Rule: Rule{Shortcut: "gnd", Prefix: "http://d-nb.info/gnd/"}, Hits: 3, Saved: 51},
+		Suggestion{Rule: Rule{Shortcut: "example2", Prefix: "http://example.org/"}, Hits: 2, Saved: 20},
+	}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("RuleSuggester.Suggest(0, 2) => %+v, want: %+v", out, want)
+	}
+	if out := s.Suggest(1, 2); len(out) != 1 {
+		t.Errorf("RuleSuggester.Suggest(1, 2) => %d suggestions, want: 1", len(out))
+	}
+}
@@ -0,0 +1,776 @@
+package ntto
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xsdNS = "http://www.w3.org/2001/XMLSchema#"
+)
+
+// term is a parsed RDF term, before it is placed into a triple.
+type term struct {
+	value    string
+	kind     Kind
+	lang     string
+	datatype string
+}
+
+// TurtleParser reads Turtle 1.1 from a reader and emits one triple at a time,
+// in the same shape ParseNTriple produces. Only the current statement is kept
+// in memory.
+type TurtleParser struct {
+	r        *bufio.Reader
+	unread   []rune
+	line     int
+	base     *url.URL
+	prefixes map[string]string
+	pending  []*Triple
+	genid    int
+}
+
+// NewTurtleParser returns a parser reading from r.
+func NewTurtleParser(r io.Reader) *TurtleParser {
+	return &TurtleParser{
+		r:        bufio.NewReader(r),
+		line:     1,
+		prefixes: make(map[string]string),
+	}
+}
+
+// Next returns the next triple or io.EOF, if there are no more triples.
+func (p *TurtleParser) Next() (*Triple, error) {
+	for len(p.pending) == 0 {
+		if err := p.statement(); err != nil {
+			return nil, err
+		}
+	}
+	t := p.pending[0]
+	p.pending = p.pending[1:]
+	return t, nil
+}
+
+func (p *TurtleParser) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("turtle: line %d: %s", p.line, fmt.Sprintf(format, a...))
+}
+
+// read returns the next rune, -1 at the end of input.
+func (p *TurtleParser) read() (rune, error) {
+	var r rune
+	if n := len(p.unread); n > 0 {
+		r = p.unread[n-1]
+		p.unread = p.unread[:n-1]
+	} else {
+		var err error
+		r, _, err = p.r.ReadRune()
+		if err == io.EOF {
+			return -1, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if r == '\n' {
+		p.line++
+	}
+	return r, nil
+}
+
+func (p *TurtleParser) back(r rune) {
+	if r == -1 {
+		return
+	}
+	if r == '\n' {
+		p.line--
+	}
+	p.unread = append(p.unread, r)
+}
+
+func (p *TurtleParser) peek() (rune, error) {
+	r, err := p.read()
+	if err != nil {
+		return 0, err
+	}
+	p.back(r)
+	return r, nil
+}
+
+// skip consumes whitespace and comments and returns the next rune without
+// consuming it.
+func (p *TurtleParser) skip() (rune, error) {
+	for {
+		r, err := p.read()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case r == '#':
+			for r != '\n' && r != -1 {
+				if r, err = p.read(); err != nil {
+					return 0, err
+				}
+			}
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+		default:
+			p.back(r)
+			return r, nil
+		}
+	}
+}
+
+func (p *TurtleParser) expect(c rune) error {
+	r, err := p.skip()
+	if err != nil {
+		return err
+	}
+	if r == -1 {
+		return p.errorf("unexpected end of input")
+	}
+	if r != c {
+		return p.errorf("expected %q, got %q", c, r)
+	}
+	_, err = p.read()
+	return err
+}
+
+func (p *TurtleParser) emit(s, pred string, o term) {
+	p.pending = append(p.pending, &Triple{
+		Subject:    s,
+		Predicate:  pred,
+		Object:     o.value,
+		ObjectKind: o.kind,
+		Lang:       o.lang,
+		Datatype:   o.datatype,
+	})
+}
+
+func (p *TurtleParser) blank() string {
+	p.genid++
+	return fmt.Sprintf("_:genid%d", p.genid)
+}
+
+// documentBlankNode returns the blank node for a label of the document. Labels
+// starting with genid are prefixed with genid_, so they never collide with
+// generated labels like _:genid1.
+func documentBlankNode(label string) string {
+	if strings.HasPrefix(label, "genid") {
+		return "_:genid_" + label
+	}
+	return "_:" + label
+}
+
+// statement parses a directive or a set of triples terminated by a dot.
+func (p *TurtleParser) statement() error {
+	r, err := p.skip()
+	if err != nil {
+		return err
+	}
+	if r == -1 {
+		return io.EOF
+	}
+	if r == '@' {
+		p.read()
+		word, err := p.word()
+		if err != nil {
+			return err
+		}
+		switch word {
+		case "prefix":
+			err = p.prefixDirective()
+		case "base":
+			err = p.baseDirective()
+		default:
+			return p.errorf("unknown directive @%s", word)
+		}
+		if err != nil {
+			return err
+		}
+		return p.expect('.')
+	}
+	var subject term
+	if r == '[' {
+		p.read()
+		if subject, err = p.blankNodePropertyList(); err != nil {
+			return err
+		}
+		if r, err = p.skip(); err != nil {
+			return err
+		}
+		if r == '.' {
+			p.read()
+			return nil
+		}
+	} else {
+		subject, err = p.subject()
+		if err != nil {
+			return err
+		}
+		if subject.kind == Literal {
+			switch strings.ToUpper(subject.value) {
+			case "PREFIX":
+				return p.prefixDirective()
+			case "BASE":
+				return p.baseDirective()
+			}
+			return p.errorf("unexpected %q", subject.value)
+		}
+	}
+	if err := p.predicateObjectList(subject.value); err != nil {
+		return err
+	}
+	return p.expect('.')
+}
+
+func (p *TurtleParser) prefixDirective() error {
+	if _, err := p.skip(); err != nil {
+		return err
+	}
+	name, err := p.word()
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(name, ":") {
+		return p.errorf("invalid prefix name %q", name)
+	}
+	if _, err := p.skip(); err != nil {
+		return err
+	}
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	p.prefixes[strings.TrimSuffix(name, ":")] = iri
+	return nil
+}
+
+func (p *TurtleParser) baseDirective() error {
+	if _, err := p.skip(); err != nil {
+		return err
+	}
+	iri, err := p.iriRef()
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(iri)
+	if err != nil {
+		return p.errorf("invalid base %q", iri)
+	}
+	p.base = u
+	return nil
+}
+
+// subject parses a subject term. A bare word that is no prefixed name comes
+// back as literal, so the caller can check for SPARQL style directives.
+func (p *TurtleParser) subject() (term, error) {
+	r, err := p.skip()
+	if err != nil {
+		return term{}, err
+	}
+	switch r {
+	case '<':
+		iri, err := p.iriRef()
+		return term{value: iri}, err
+	case '(':
+		p.read()
+		return p.collection()
+	case '_':
+		return p.blankNodeLabel()
+	}
+	word, err := p.word()
+	if err != nil {
+		return term{}, err
+	}
+	if !strings.Contains(word, ":") {
+		return term{value: word, kind: Literal}, nil
+	}
+	iri, err := p.expand(word)
+	return term{value: iri}, err
+}
+
+func (p *TurtleParser) predicateObjectList(subject string) error {
+	for {
+		r, err := p.skip()
+		if err != nil {
+			return err
+		}
+		if r == '.' || r == ']' || r == -1 {
+			return nil
+		}
+		predicate, err := p.verb()
+		if err != nil {
+			return err
+		}
+		if err := p.objectList(subject, predicate); err != nil {
+			return err
+		}
+		if r, err = p.skip(); err != nil {
+			return err
+		}
+		if r != ';' {
+			return nil
+		}
+		for r == ';' {
+			p.read()
+			if r, err = p.skip(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *TurtleParser) verb() (string, error) {
+	r, err := p.skip()
+	if err != nil {
+		return "", err
+	}
+	if r == '<' {
+		return p.iriRef()
+	}
+	word, err := p.word()
+	if err != nil {
+		return "", err
+	}
+	if word == "a" {
+		return rdfNS + "type", nil
+	}
+	return p.expand(word)
+}
+
+func (p *TurtleParser) objectList(subject, predicate string) error {
+	for {
+		o, err := p.object()
+		if err != nil {
+			return err
+		}
+		p.emit(subject, predicate, o)
+		r, err := p.skip()
+		if err != nil {
+			return err
+		}
+		if r != ',' {
+			return nil
+		}
+		p.read()
+	}
+}
+
+func (p *TurtleParser) object() (term, error) {
+	r, err := p.skip()
+	if err != nil {
+		return term{}, err
+	}
+	switch {
+	case r == '<':
+		iri, err := p.iriRef()
+		return term{value: iri}, err
+	case r == '_':
+		return p.blankNodeLabel()
+	case r == '[':
+		p.read()
+		return p.blankNodePropertyList()
+	case r == '(':
+		p.read()
+		return p.collection()
+	case r == '"' || r == '\'':
+		return p.rdfLiteral()
+	case r == '+' || r == '-' || r == '.' || (r >= '0' && r <= '9'):
+		return p.numericLiteral()
+	case r == -1:
+		return term{}, p.errorf("unexpected end of input")
+	}
+	word, err := p.word()
+	if err != nil {
+		return term{}, err
+	}
+	switch word {
+	case "true", "false":
+		return term{value: word, kind: Literal, datatype: xsdNS + "boolean"}, nil
+	case "":
+		return term{}, p.errorf("unexpected %q", r)
+	}
+	iri, err := p.expand(word)
+	return term{value: iri}, err
+}
+
+// blankNodePropertyList parses the part after an opening bracket and returns
+// the blank node it describes.
+func (p *TurtleParser) blankNodePropertyList() (term, error) {
+	node := term{value: p.blank(), kind: BlankNode}
+	if err := p.predicateObjectList(node.value); err != nil {
+		return term{}, err
+	}
+	return node, p.expect(']')
+}
+
+// collection parses the part after an opening parenthesis into an RDF list.
+func (p *TurtleParser) collection() (term, error) {
+	head := term{value: rdfNS + "nil"}
+	var last string
+	for {
+		r, err := p.skip()
+		if err != nil {
+			return term{}, err
+		}
+		if r == ')' {
+			p.read()
+			break
+		}
+		node := p.blank()
+		if last == "" {
+			head = term{value: node, kind: BlankNode}
+		} else {
+			p.emit(last, rdfNS+"rest", term{value: node, kind: BlankNode})
+		}
+		o, err := p.object()
+		if err != nil {
+			return term{}, err
+		}
+		p.emit(node, rdfNS+"first", o)
+		last = node
+	}
+	if last != "" {
+		p.emit(last, rdfNS+"rest", term{value: rdfNS + "nil"})
+	}
+	return head, nil
+}
+
+func (p *TurtleParser) blankNodeLabel() (term, error) {
+	word, err := p.word()
+	if err != nil {
+		return term{}, err
+	}
+	if !strings.HasPrefix(word, "_:") || len(word) < 3 {
+		return term{}, p.errorf("invalid blank node %q", word)
+	}
+	return term{value: documentBlankNode(word[2:]), kind: BlankNode}, nil
+}
+
+// iriRef reads an IRI in angle brackets and resolves it against the base.
+func (p *TurtleParser) iriRef() (string, error) {
+	if err := p.expect('<'); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for {
+		r, err := p.read()
+		if err != nil {
+			return "", err
+		}
+		switch r {
+		case '>':
+			return p.resolve(sb.String()), nil
+		case -1, '\n', ' ', '<', '"':
+			return "", p.errorf("invalid IRI %q", sb.String())
+		case '\\':
+			if r, err = p.unicodeEscape(); err != nil {
+				return "", err
+			}
+		}
+		sb.WriteRune(r)
+	}
+}
+
+func (p *TurtleParser) resolve(iri string) string {
+	if p.base == nil {
+		return iri
+	}
+	u, err := url.Parse(iri)
+	if err != nil || u.IsAbs() {
+		return iri
+	}
+	return p.base.ResolveReference(u).String()
+}
+
+// expand turns a prefixed name into an IRI.
+func (p *TurtleParser) expand(pname string) (string, error) {
+	i := strings.Index(pname, ":")
+	if i < 0 {
+		return "", p.errorf("unexpected %q", pname)
+	}
+	ns, ok := p.prefixes[pname[:i]]
+	if !ok {
+		return "", p.errorf("undefined prefix %q", pname[:i])
+	}
+	return ns + pname[i+1:], nil
+}
+
+func isNameRune(r rune) bool {
+	switch r {
+	case '_', '-', '.', ':', '%', '·':
+		return true
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// word reads a prefixed name, blank node label or keyword. Reserved character
+// escapes are resolved and a trailing dot is left for the statement.
+func (p *TurtleParser) word() (string, error) {
+	var rs []rune
+	for {
+		r, err := p.read()
+		if err != nil {
+			return "", err
+		}
+		if r == '\\' {
+			if r, err = p.read(); err != nil {
+				return "", err
+			}
+			if r == -1 {
+				return "", p.errorf("unexpected end of input")
+			}
+			if !strings.ContainsRune("_~.-!$&'()*+,;=/?#@%", r) {
+				return "", p.errorf("invalid escape \\%c", r)
+			}
+			rs = append(rs, r)
+			continue
+		}
+		if !isNameRune(r) {
+			p.back(r)
+			break
+		}
+		rs = append(rs, r)
+	}
+	for len(rs) > 0 && rs[len(rs)-1] == '.' {
+		p.back('.')
+		rs = rs[:len(rs)-1]
+	}
+	return string(rs), nil
+}
+
+func (p *TurtleParser) unicodeEscape() (rune, error) {
+	r, err := p.read()
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	switch r {
+	case 'u':
+		n = 4
+	case 'U':
+		n = 8
+	default:
+		return 0, p.errorf("invalid escape \\%c", r)
+	}
+	hex := make([]rune, n)
+	for i := range hex {
+		if hex[i], err = p.read(); err != nil {
+			return 0, err
+		}
+		if hex[i] == -1 {
+			return 0, p.errorf("unexpected end of input")
+		}
+	}
+	v, err := strconv.ParseUint(string(hex), 16, 32)
+	if err != nil {
+		return 0, p.errorf("invalid escape \\%c%s", r, string(hex))
+	}
+	return rune(v), nil
+}
+
+func (p *TurtleParser) rdfLiteral() (term, error) {
+	s, err := p.quoted()
+	if err != nil {
+		return term{}, err
+	}
+	t := term{value: escapeLiteral(s), kind: Literal}
+	r, err := p.peek()
+	if err != nil {
+		return term{}, err
+	}
+	switch r {
+	case '@':
+		p.read()
+		lang, err := p.word()
+		if err != nil {
+			return term{}, err
+		}
+		t.lang = lang
+	case '^':
+		p.read()
+		if r, _ := p.read(); r != '^' {
+			return term{}, p.errorf("expected ^^")
+		}
+		dt, err := p.verb()
+		if err != nil {
+			return term{}, err
+		}
+		t.datatype = dt
+	}
+	return t, nil
+}
+
+// quoted reads any of the four string forms and resolves escapes.
+func (p *TurtleParser) quoted() (string, error) {
+	q, err := p.read()
+	if err != nil {
+		return "", err
+	}
+	long := false
+	r, err := p.read()
+	if err != nil {
+		return "", err
+	}
+	if r == q {
+		r2, err := p.read()
+		if err != nil {
+			return "", err
+		}
+		if r2 != q {
+			p.back(r2)
+			return "", nil
+		}
+		long = true
+	} else {
+		p.back(r)
+	}
+	var sb strings.Builder
+	for {
+		r, err := p.read()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case r == -1:
+			return "", p.errorf("unterminated string")
+		case r == q && !long:
+			return sb.String(), nil
+		case r == q:
+			// up to two quotes may come right before the closing ones
+			n := 1
+			for {
+				r, err = p.read()
+				if err != nil {
+					return "", err
+				}
+				if r != q {
+					p.back(r)
+					break
+				}
+				n++
+			}
+			if n > 5 {
+				return "", p.errorf("unexpected %s", strings.Repeat(string(q), n))
+			}
+			if n >= 3 {
+				sb.WriteString(strings.Repeat(string(q), n-3))
+				return sb.String(), nil
+			}
+			sb.WriteString(strings.Repeat(string(q), n))
+		case r == '\n' && !long:
+			return "", p.errorf("newline in string")
+		case r == '\\':
+			r, err = p.read()
+			if err != nil {
+				return "", err
+			}
+			switch r {
+			case 't':
+				sb.WriteRune('\t')
+			case 'b':
+				sb.WriteRune('\b')
+			case 'n':
+				sb.WriteRune('\n')
+			case 'r':
+				sb.WriteRune('\r')
+			case 'f':
+				sb.WriteRune('\f')
+			case '"', '\'', '\\':
+				sb.WriteRune(r)
+			case 'u', 'U':
+				p.back(r)
+				if r, err = p.unicodeEscape(); err != nil {
+					return "", err
+				}
+				sb.WriteRune(r)
+			case -1:
+				return "", p.errorf("unterminated string")
+			default:
+				return "", p.errorf("invalid escape \\%c", r)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+}
+
+func (p *TurtleParser) numericLiteral() (term, error) {
+	var sb strings.Builder
+	digits := func() error {
+		for {
+			r, err := p.read()
+			if err != nil {
+				return err
+			}
+			if r < '0' || r > '9' {
+				p.back(r)
+				return nil
+			}
+			sb.WriteRune(r)
+		}
+	}
+	datatype := xsdNS + "integer"
+	r, err := p.read()
+	if err != nil {
+		return term{}, err
+	}
+	if r == '+' || r == '-' {
+		sb.WriteRune(r)
+	} else {
+		p.back(r)
+	}
+	if err := digits(); err != nil {
+		return term{}, err
+	}
+	if r, err = p.read(); err != nil {
+		return term{}, err
+	}
+	if r == '.' {
+		next, err := p.peek()
+		if err != nil {
+			return term{}, err
+		}
+		if next >= '0' && next <= '9' {
+			sb.WriteRune(r)
+			datatype = xsdNS + "decimal"
+			if err := digits(); err != nil {
+				return term{}, err
+			}
+		} else if next == 'e' || next == 'E' {
+			sb.WriteRune(r)
+		} else {
+			p.back(r)
+		}
+		if r, err = p.read(); err != nil {
+			return term{}, err
+		}
+	}
+	if r == 'e' || r == 'E' {
+		sb.WriteRune(r)
+		datatype = xsdNS + "double"
+		if r, err = p.read(); err != nil {
+			return term{}, err
+		}
+		if r == '+' || r == '-' {
+			sb.WriteRune(r)
+		} else {
+			p.back(r)
+		}
+		if err := digits(); err != nil {
+			return term{}, err
+		}
+	} else {
+		p.back(r)
+	}
+	s := sb.String()
+	if strings.Trim(s, "+-.eE") == "" || strings.ContainsAny(s[len(s)-1:], "eE+-") {
+		return term{}, p.errorf("invalid number %q", s)
+	}
+	return term{value: s, kind: Literal, datatype: datatype}, nil
+}
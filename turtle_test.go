@@ -0,0 +1,138 @@
+package ntto
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var TurtleParserTests = []struct {
+	in  string
+	out []string
+}{
+	{`<a> <b> <c> .`,
+		[]string{`<a> <b> <c> .`}},
+	{`@prefix foaf: <http://xmlns.com/foaf/0.1/> .
+      <a> foaf:name "Alice" ; foaf:knows <b>, <c> .`,
+		[]string{`<a> <http://xmlns.com/foaf/0.1/name> "Alice" .`,
+			`<a> <http://xmlns.com/foaf/0.1/knows> <b> .`,
+			`<a> <http://xmlns.com/foaf/0.1/knows> <c> .`}},
+	{`PREFIX : <http://example.org/>
+      BASE <http://example.org/base/>
+      :a a <Thing> .`,
+		[]string{`<http://example.org/a> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://example.org/base/Thing> .`}},
+	{`<a> <b> "hallo"@de, "x"^^<d>, 'single' . # comment`,
+		[]string{`<a> <b> "hallo"@de .`,
+			`<a> <b> "x"^^<d> .`,
+			`<a> <b> "single" .`}},
+	{`<a> <b> """long "quoted"
+string""" .`,
+		[]string{`<a> <b> "long \"quoted\"\nstring" .`}},
+	{`<a> <b> 1, -2.5, 1e10, true .`,
+		[]string{`<a> <b> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+			`<a> <b> "-2.5"^^<http://www.w3.org/2001/XMLSchema#decimal> .`,
+			`<a> <b> "1e10"^^<http://www.w3.org/2001/XMLSchema#double> .`,
+			`<a> <b> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .`}},
+	{`<a> <b> 1.`,
+		[]string{`<a> <b> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`}},
+	{`<a> <b> [ <c> <d> ] .`,
+		[]string{`_:genid1 <c> <d> .`,
+			`<a> <b> _:genid1 .`}},
+	{`[ <c> <d> ] .
+      [] <e> _:x .`,
+		[]string{`_:genid1 <c> <d> .`,
+			`_:genid2 <e> _:x .`}},
+	{`<a> <b> ( <c> "d" ) .`,
+		[]string{`_:genid1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> <c> .`,
+			`_:genid1 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> _:genid2 .`,
+			`_:genid2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#first> "d" .`,
+			`_:genid2 <http://www.w3.org/1999/02/22-rdf-syntax-ns#rest> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`,
+			`<a> <b> _:genid1 .`}},
+	{`<a> <b> () .`,
+		[]string{`<a> <b> <http://www.w3.org/1999/02/22-rdf-syntax-ns#nil> .`}},
+	{`@prefix ex: <http://ex/> . ex:a\.b ex:c ex:d.`,
+		[]string{`<http://ex/a.b> <http://ex/c> <http://ex/d> .`}},
+	{`<a> <b> """a"""", '''b''''', """c""" .`,
+		[]string{`<a> <b> "a\"" .`, `<a> <b> "b''" .`, `<a> <b> "c" .`}},
+	{`_:genid1 <b> [ <c> _:genid_x ] .`,
+		[]string{`_:genid1 <c> _:genid_genid_x .`,
+			`_:genid_genid1 <b> _:genid1 .`}},
+	{`<a> <b> "a\u0001b\u007F\b\n" .`,
+		[]string{`<a> <b> "a\u0001b\u007F\u0008\n" .`}},
+}
+
+func TestTurtleParser(t *testing.T) {
+	for _, tt := range TurtleParserTests {
+		p := NewTurtleParser(strings.NewReader(tt.in))
+		var out []string
+		for {
+			triple, err := p.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("TurtleParser(%s) failed: %s", tt.in, err)
+			}
+			out = append(out, triple.String())
+		}
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("TurtleParser(%s) => %q, want: %q", tt.in, out, tt.out)
+		}
+	}
+}
+
+// Turtle that only uses N-Triples syntax must give the same triples.
+func TestTurtleParserNTriples(t *testing.T) {
+	lines := []string{
+		`<http://d-nb.info/gnd/1-2> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://d-nb.info/standards/elementset/gnd#SeriesOfConferenceOrEvent> .`,
+		`<a> <b> "the deep blue c"@en .`,
+		`<a> <b> "say \"c\"" .`,
+		`_:b0 <b> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+	}
+	p := NewTurtleParser(strings.NewReader(strings.Join(lines, "\n")))
+	for _, line := range lines {
+		want, err := ParseNTriple(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := p.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != *want {
+			t.Errorf("TurtleParser(%s) => %#v, want: %#v", line, got, want)
+		}
+	}
+}
+
+var TurtleParserErrorTests = []string{
+	`<a> <b> .`,
+	`<a> <b> <c>`,
+	`foaf:a <b> <c> .`,
+	`<a> <b> "unterminated .`,
+	`@foo <a> .`,
+	`<a> <b> """a"""""" .`,
+}
+
+func TestTurtleParserError(t *testing.T) {
+	for _, in := range TurtleParserErrorTests {
+		p := NewTurtleParser(strings.NewReader(in))
+		var err error
+		for err == nil {
+			_, err = p.Next()
+		}
+		if err == io.EOF {
+			t.Errorf("TurtleParser(%s) => no error", in)
+		}
+	}
+}
+
+func TestTurtleParserEndOfInput(t *testing.T) {
+	for _, in := range []string{`<a> <b> <c>`, `<a> <b> "c\`, `<a> <b> "\u00`, `<a> <b> `} {
+		_, err := NewTurtleParser(strings.NewReader(in)).Next()
+		if err == nil || !strings.Contains(err.Error(), "end of input") && !strings.Contains(err.Error(), "unterminated") {
+			t.Errorf("TurtleParser(%s) => %v, want end of input", in, err)
+		}
+	}
+}
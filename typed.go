@@ -0,0 +1,176 @@
+package ntto
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xsdDouble matches the lexical forms of xsd:double and xsd:float, except
+// INF, -INF and NaN.
+var xsdDouble = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// xsdIntegers are xsd:integer and the types derived from it.
+var xsdIntegers = map[string]bool{
+	"integer": true, "long": true, "int": true, "short": true, "byte": true,
+	"nonNegativeInteger": true, "positiveInteger": true, "nonPositiveInteger": true,
+	"negativeInteger": true, "unsignedLong": true, "unsignedInt": true,
+	"unsignedShort": true, "unsignedByte": true,
+}
+
+// TypedLiterals turns literals typed xsd:integer (or derived types),
+// xsd:decimal, xsd:double, xsd:float and xsd:boolean into JSON numbers and
+// booleans, and normalizes xsd:date and xsd:dateTime to ISO 8601 strings.
+// Literals with an invalid lexical form, or values JSON cannot represent,
+// like INF, stay strings and are counted. A TypedLiterals must not be used by
+// several encoders at the same time.
+type TypedLiterals struct {
+	index     *prefixIndex
+	fallbacks int64
+}
+
+// NewTypedLiterals returns a converter. Abbreviated datatypes, like
+// xsd:integer after ntto abbreviate, are recognized with rules.
+func NewTypedLiterals(rules []Rule) *TypedLiterals {
+	return &TypedLiterals{index: newPrefixIndex(rules)}
+}
+
+// Fallbacks returns the number of typed literals kept as strings.
+func (c *TypedLiterals) Fallbacks() int64 {
+	return c.fallbacks
+}
+
+// Value returns the object of a triple as a JSON value: a json.Number, a
+// bool or a string.
+func (c *TypedLiterals) Value(t *Triple) interface{} {
+	if t.ObjectKind != Literal || t.Datatype == "" {
+		return t.Object
+	}
+	datatype := c.index.expand(t.Datatype)
+	if !strings.HasPrefix(datatype, xsdNS) {
+		return t.Object
+	}
+	var v interface{}
+	var ok bool
+	switch name := datatype[len(xsdNS):]; {
+	case xsdIntegers[name]:
+		v, ok = jsonNumber(t.Object, xsdLexical[xsdNS+"integer"])
+	case name == "decimal":
+		v, ok = jsonNumber(t.Object, xsdLexical[xsdNS+"decimal"])
+	case name == "double" || name == "float":
+		v, ok = jsonFloat(t.Object)
+	case name == "boolean":
+		switch strings.TrimSpace(t.Object) {
+		case "true", "1":
+			v, ok = true, true
+		case "false", "0":
+			v, ok = false, true
+		}
+	case name == "date":
+		v, ok = isoDate(strings.TrimSpace(t.Object))
+	case name == "dateTime":
+		v, ok = isoDateTime(strings.TrimSpace(t.Object))
+	default:
+		return t.Object
+	}
+	if !ok {
+		c.fallbacks++
+		return t.Object
+	}
+	return v
+}
+
+// jsonNumber turns an integer or decimal lexical form into a JSON number,
+// keeping all digits.
+func jsonNumber(s string, re *regexp.Regexp) (json.Number, bool) {
+	s = strings.TrimSpace(s)
+	if !re.MatchString(s) {
+		return "", false
+	}
+	sign := ""
+	if s[0] == '-' || s[0] == '+' {
+		if s[0] == '-' {
+			sign = "-"
+		}
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], strings.TrimRight(s[i+1:], "0")
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if intPart == "0" && fracPart == "" {
+		sign = ""
+	}
+	if fracPart != "" {
+		return json.Number(sign + intPart + "." + fracPart), true
+	}
+	return json.Number(sign + intPart), true
+}
+
+// jsonFloat turns a double or float lexical form into a JSON number. INF,
+// -INF, NaN and values out of range have no JSON representation.
+func jsonFloat(s string) (json.Number, bool) {
+	s = strings.TrimSpace(s)
+	if !xsdDouble.MatchString(s) {
+		return "", false
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) {
+		return "", false
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), true
+}
+
+// isoDate checks an xsd:date. Dates with a time zone become the start of the
+// day in that zone, like 2006-01-02T00:00:00+01:00, since ISO 8601 has no
+// dates with time zone.
+func isoDate(s string) (string, bool) {
+	if len(s) == len("2006-01-02") {
+		_, err := time.Parse("2006-01-02", s)
+		return s, err == nil
+	}
+	t, err := time.Parse("2006-01-02Z07:00", s)
+	if err != nil {
+		return "", false
+	}
+	return t.Format(time.RFC3339), true
+}
+
+// isoDateTime checks an xsd:dateTime and formats it as RFC 3339, or without
+// time zone, if it has none. The end of day, 24:00:00, becomes midnight of
+// the next day.
+func isoDateTime(s string) (string, bool) {
+	endOfDay := false
+	if i := strings.Index(s, "T24:00:00"); i >= 0 {
+		rest := s[i+len("T24:00:00"):]
+		if strings.HasPrefix(rest, ".") {
+			// only a zero fraction is allowed
+			rest = strings.TrimLeft(rest[1:], "0")
+			if rest != "" && rest[0] >= '0' && rest[0] <= '9' {
+				return "", false
+			}
+		}
+		s, endOfDay = s[:i]+"T00:00:00"+rest, true
+	}
+	var layout, format string
+	if strings.HasSuffix(s, "Z") || strings.LastIndexAny(s, "+-") > strings.IndexByte(s, 'T') {
+		layout, format = "2006-01-02T15:04:05Z07:00", time.RFC3339Nano
+	} else {
+		layout, format = "2006-01-02T15:04:05", "2006-01-02T15:04:05.999999999"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return "", false
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t.Format(format), true
+}
@@ -0,0 +1,92 @@
+package ntto
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+var TypedLiteralsTests = []struct {
+	line string
+	out  interface{}
+}{
+	{`<a> <p> "+007"^^<http://www.w3.org/2001/XMLSchema#integer> .`, json.Number("7")},
+	{`<a> <p> "-0"^^<http://www.w3.org/2001/XMLSchema#int> .`, json.Number("0")},
+	{`<a> <p> "123456789012345678901234567890"^^<http://www.w3.org/2001/XMLSchema#integer> .`, json.Number("123456789012345678901234567890")},
+	{`<a> <p> "1.5e3"^^<http://www.w3.org/2001/XMLSchema#integer> .`, "1.5e3"},
+	{`<a> <p> "-01.2500"^^<http://www.w3.org/2001/XMLSchema#decimal> .`, json.Number("-1.25")},
+	{`<a> <p> ".5"^^<http://www.w3.org/2001/XMLSchema#decimal> .`, json.Number("0.5")},
+	{`<a> <p> "1.5E3"^^<http://www.w3.org/2001/XMLSchema#double> .`, json.Number("1500")},
+	{`<a> <p> "INF"^^<http://www.w3.org/2001/XMLSchema#double> .`, "INF"},
+	{`<a> <p> "NaN"^^<http://www.w3.org/2001/XMLSchema#float> .`, "NaN"},
+	{`<a> <p> "1"^^<http://www.w3.org/2001/XMLSchema#boolean> .`, true},
+	{`<a> <p> "false"^^<http://www.w3.org/2001/XMLSchema#boolean> .`, false},
+	{`<a> <p> "yes"^^<http://www.w3.org/2001/XMLSchema#boolean> .`, "yes"},
+	{`<a> <p> "2006-01-02"^^<http://www.w3.org/2001/XMLSchema#date> .`, "2006-01-02"},
+	{`<a> <p> "2006-01-02+01:00"^^<http://www.w3.org/2001/XMLSchema#date> .`, "2006-01-02T00:00:00+01:00"},
+	{`<a> <p> "2006-02-30"^^<http://www.w3.org/2001/XMLSchema#date> .`, "2006-02-30"},
+	{`<a> <p> "2006-01-02T15:04:05.500Z"^^<http://www.w3.org/2001/XMLSchema#dateTime> .`, "2006-01-02T15:04:05.5Z"},
+	{`<a> <p> "2006-01-02T15:04:05"^^<http://www.w3.org/2001/XMLSchema#dateTime> .`, "2006-01-02T15:04:05"},
+	{`<a> <p> "2006-12-31T24:00:00-05:00"^^<http://www.w3.org/2001/XMLSchema#dateTime> .`, "2007-01-01T00:00:00-05:00"},
+	{`<a> <p> "42"^^<xsd:integer> .`, json.Number("42")},
+	{`<a> <p> "42"^^<http://example.org/number> .`, "42"},
+	{`<a> <p> "42"@en .`, "42"},
+	{`<a> <p> "42" .`, "42"},
+	{`<a> <p> <b> .`, "b"},
+}
+
+func TestTypedLiterals(t *testing.T) {
+	typed := NewTypedLiterals([]Rule{{Shortcut: "xsd", Prefix: xsdNS}})
+	for _, tt := range TypedLiteralsTests {
+		triple, err := ParseNTriple(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := typed.Value(triple); got != tt.out {
+			t.Errorf("Value(%s): got %#v, want %#v", tt.line, got, tt.out)
+		}
+	}
+	if got := typed.Fallbacks(); got != 5 {
+		t.Errorf("got %d fallbacks, want 5", got)
+	}
+}
+
+func TestTypedEncoders(t *testing.T) {
+	triples := []string{
+		`<a> <p> "1"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		`<a> <q> "true"^^<http://www.w3.org/2001/XMLSchema#boolean> .`,
+	}
+	var tests = []struct {
+		format  string
+		grouped bool
+		out     string
+	}{
+		{"json", false, `{"s":"a","p":"p","o":1}
+{"s":"a","p":"q","o":true}
+`},
+		{"json", true, `{"@id":"a","p":[1],"q":[true]}
+`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		e, err := NewEncoder(tt.format, &buf, EncoderOptions{Grouped: tt.grouped, Typed: NewTypedLiterals(nil)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range triples {
+			triple, err := ParseNTriple(line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Encode(triple); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := e.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != tt.out {
+			t.Errorf("%s (grouped %v): got %s, want %s", tt.format, tt.grouped, buf.String(), tt.out)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package ntto
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severities of validation problems. Errors make a line invalid N-Triples,
+// warnings flag valid N-Triples that is most likely wrong.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is a single validation finding. Line and column are 1-based, the
+// column counts bytes.
+type Problem struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, p.Severity, p.Message)
+}
+
+var (
+	iriScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+	// bcp47 is the shape of a language tag from RFC 5646, without checking
+	// subtags against the registry.
+	bcp47 = regexp.MustCompile(`(?i)^(` +
+		`[a-z]{2,3}(-[a-z]{3}){0,3}|[a-z]{4,8})` +
+		`(-[a-z]{4})?` +
+		`(-([a-z]{2}|[0-9]{3}))?` +
+		`(-([a-z0-9]{5,8}|[0-9][a-z0-9]{3}))*` +
+		`(-[0-9a-wyz](-[a-z0-9]{2,8})+)*` +
+		`(-x(-[a-z0-9]{1,8})+)?$|^x(-[a-z0-9]{1,8})+$`)
+	// xsdDatatypes are the datatypes XML Schema defines, which RDF can use.
+	xsdDatatypes = map[string]bool{
+		"string": true, "boolean": true, "decimal": true, "integer": true,
+		"double": true, "float": true, "date": true, "time": true,
+		"dateTime": true, "dateTimeStamp": true, "gYear": true, "gMonth": true,
+		"gDay": true, "gYearMonth": true, "gMonthDay": true, "duration": true,
+		"yearMonthDuration": true, "dayTimeDuration": true, "byte": true,
+		"short": true, "int": true, "long": true, "unsignedByte": true,
+		"unsignedShort": true, "unsignedInt": true, "unsignedLong": true,
+		"positiveInteger": true, "nonNegativeInteger": true,
+		"negativeInteger": true, "nonPositiveInteger": true, "hexBinary": true,
+		"base64Binary": true, "anyURI": true, "language": true,
+		"normalizedString": true, "token": true, "NMTOKEN": true, "Name": true,
+		"NCName": true,
+	}
+	xsdLexical = map[string]*regexp.Regexp{
+		xsdNS + "integer": regexp.MustCompile(`^[+-]?[0-9]+$`),
+		xsdNS + "decimal": regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`),
+		xsdNS + "boolean": regexp.MustCompile(`^(true|false|1|0)$`),
+		xsdNS + "date":    regexp.MustCompile(`^-?([1-9][0-9]{3,}|0[0-9]{3})-(0[1-9]|1[0-2])-(0[1-9]|[12][0-9]|3[01])(Z|[+-]((0[0-9]|1[0-3]):[0-5][0-9]|14:00))?$`),
+	}
+)
+
+// lineValidator checks a single line against the N-Triples grammar.
+type lineValidator struct {
+	s        string
+	pos      int
+	problems []Problem
+}
+
+func (v *lineValidator) report(pos int, severity, format string, a ...interface{}) {
+	v.problems = append(v.problems, Problem{
+		Column:   pos + 1,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (v *lineValidator) skipSpace() {
+	for v.pos < len(v.s) && (v.s[v.pos] == ' ' || v.s[v.pos] == '\t') {
+		v.pos++
+	}
+}
+
+func (v *lineValidator) peek() byte {
+	if v.pos < len(v.s) {
+		return v.s[v.pos]
+	}
+	return 0
+}
+
+// ValidateNTriple checks a line against the N-Triples grammar and checks
+// IRIs, language tags and the lexical forms of common XSD datatypes. Empty
+// lines and comments are valid. The problems returned have no line number.
+func ValidateNTriple(line string) []Problem {
+	v := &lineValidator{s: strings.TrimRight(line, "\r\n")}
+	if !utf8.ValidString(v.s) {
+		v.report(0, SeverityError, "invalid UTF-8")
+		return v.problems
+	}
+	v.skipSpace()
+	if v.pos == len(v.s) || v.peek() == '#' {
+		return nil
+	}
+	if !v.subject() {
+		return v.problems
+	}
+	v.skipSpace()
+	if v.peek() != '<' {
+		v.report(v.pos, SeverityError, "expected predicate IRI")
+		return v.problems
+	}
+	if _, ok := v.iri(); !ok {
+		return v.problems
+	}
+	v.skipSpace()
+	if !v.object() {
+		return v.problems
+	}
+	v.skipSpace()
+	if v.peek() != '.' {
+		v.report(v.pos, SeverityError, "expected '.' at end of triple")
+		return v.problems
+	}
+	v.pos++
+	v.skipSpace()
+	if v.pos < len(v.s) && v.peek() != '#' {
+		v.report(v.pos, SeverityError, "unexpected %q after end of triple", v.s[v.pos:])
+	}
+	return v.problems
+}
+
+func (v *lineValidator) subject() bool {
+	switch v.peek() {
+	case '<':
+		_, ok := v.iri()
+		return ok
+	case '_':
+		return v.blankNode()
+	}
+	v.report(v.pos, SeverityError, "expected subject IRI or blank node")
+	return false
+}
+
+func (v *lineValidator) object() bool {
+	switch v.peek() {
+	case '<':
+		_, ok := v.iri()
+		return ok
+	case '_':
+		return v.blankNode()
+	case '"':
+		return v.literal()
+	}
+	v.report(v.pos, SeverityError, "expected object IRI, blank node or literal")
+	return false
+}
+
+// iri reads an IRIREF and returns the IRI with escapes resolved.
+func (v *lineValidator) iri() (string, bool) {
+	start := v.pos
+	v.pos++
+	var sb strings.Builder
+	for {
+		if v.pos >= len(v.s) {
+			v.report(start, SeverityError, "unterminated IRI")
+			return "", false
+		}
+		c := v.s[v.pos]
+		switch {
+		case c == '>':
+			v.pos++
+			iri := sb.String()
+			v.checkIRI(start, iri)
+			return iri, true
+		case c == '\\':
+			r, ok := v.uchar()
+			if !ok {
+				return "", false
+			}
+			sb.WriteRune(r)
+			continue
+		case c <= ' ' || strings.IndexByte("<\"{}|^`", c) >= 0:
+			v.report(v.pos, SeverityError, "character %q not allowed in IRI", c)
+			return "", false
+		}
+		sb.WriteByte(c)
+		v.pos++
+	}
+}
+
+// checkIRI reports IRIs that are relative or have broken percent-encodings.
+func (v *lineValidator) checkIRI(pos int, iri string) {
+	if !iriScheme.MatchString(iri) {
+		v.report(pos, SeverityError, "IRI <%s> is not absolute", iri)
+	}
+	for i := 0; i < len(iri); i++ {
+		if iri[i] != '%' {
+			continue
+		}
+		if i+2 >= len(iri) || !isHex(iri[i+1]) || !isHex(iri[i+2]) {
+			v.report(pos, SeverityError, "IRI <%s> has an invalid percent-encoding", iri)
+			return
+		}
+	}
+}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+// uchar reads a \u or \U escape at the current position.
+func (v *lineValidator) uchar() (rune, bool) {
+	n := 0
+	if v.pos+1 < len(v.s) {
+		switch v.s[v.pos+1] {
+		case 'u':
+			n = 4
+		case 'U':
+			n = 8
+		}
+	}
+	if n == 0 || v.pos+2+n > len(v.s) {
+		v.report(v.pos, SeverityError, "invalid escape sequence")
+		return 0, false
+	}
+	hex := v.s[v.pos+2 : v.pos+2+n]
+	r, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(r)) {
+		v.report(v.pos, SeverityError, "invalid escape sequence \\%c%s", v.s[v.pos+1], hex)
+		return 0, false
+	}
+	v.pos += 2 + n
+	return rune(r), true
+}
+
+func (v *lineValidator) blankNode() bool {
+	start := v.pos
+	if !strings.HasPrefix(v.s[v.pos:], "_:") {
+		v.report(v.pos, SeverityError, "expected blank node label")
+		return false
+	}
+	v.pos += 2
+	label := v.pos
+	for v.pos < len(v.s) {
+		r, size := utf8.DecodeRuneInString(v.s[v.pos:])
+		if r == ' ' || r == '\t' || r == '<' || r == '"' || r == '#' {
+			break
+		}
+		v.pos += size
+	}
+	// a trailing dot belongs to the end of the triple
+	for v.pos > label && v.s[v.pos-1] == '.' {
+		v.pos--
+	}
+	name := v.s[label:v.pos]
+	if name == "" || !isBlankNodeLabel(name) {
+		v.report(start, SeverityError, "invalid blank node label %q", v.s[start:v.pos])
+		return false
+	}
+	return true
+}
+
+func isBlankNodeLabel(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_' || ('A' <= r && r <= 'Z') || ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') || r >= 0xC0:
+		case i > 0 && (r == '-' || r == '.' || r == 0xB7):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (v *lineValidator) literal() bool {
+	start := v.pos
+	v.pos++
+	var sb strings.Builder
+	for {
+		if v.pos >= len(v.s) {
+			v.report(start, SeverityError, "unterminated literal")
+			return false
+		}
+		c := v.s[v.pos]
+		if c == '"' {
+			v.pos++
+			break
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			v.pos++
+			continue
+		}
+		if v.pos+1 >= len(v.s) {
+			v.report(v.pos, SeverityError, "invalid escape sequence")
+			return false
+		}
+		switch e := v.s[v.pos+1]; e {
+		case 'u', 'U':
+			r, ok := v.uchar()
+			if !ok {
+				return false
+			}
+			sb.WriteRune(r)
+			continue
+		case 't', 'b', 'n', 'r', 'f', '"', '\'', '\\':
+			sb.WriteString(unescapeLiteral(v.s[v.pos : v.pos+2]))
+		default:
+			v.report(v.pos, SeverityError, "invalid escape sequence \\%c", e)
+			return false
+		}
+		v.pos += 2
+	}
+	switch {
+	case v.peek() == '@':
+		tagStart := v.pos
+		v.pos++
+		for v.pos < len(v.s) && (isAlnum(v.s[v.pos]) || v.s[v.pos] == '-') {
+			v.pos++
+		}
+		tag := v.s[tagStart+1 : v.pos]
+		if !validLangTag(tag) {
+			v.report(tagStart, SeverityError, "invalid language tag %q", tag)
+			return false
+		}
+		if !bcp47.MatchString(tag) {
+			v.report(tagStart, SeverityError, "language tag %q is not well-formed BCP47", tag)
+			return false
+		}
+		if c := canonicalLangTag(tag); c != tag {
+			v.report(tagStart, SeverityWarning, "language tag %q is usually written %q", tag, c)
+		}
+	case strings.HasPrefix(v.s[v.pos:], "^^"):
+		v.pos += 2
+		if v.peek() != '<' {
+			v.report(v.pos, SeverityError, "expected datatype IRI")
+			return false
+		}
+		datatypeStart := v.pos
+		datatype, ok := v.iri()
+		if !ok {
+			return false
+		}
+		if name := strings.TrimPrefix(datatype, xsdNS); name != datatype && !xsdDatatypes[name] {
+			v.report(datatypeStart, SeverityWarning, "unknown XML Schema datatype <%s>", datatype)
+		}
+		if re, ok := xsdLexical[datatype]; ok && !validLexical(datatype, re, sb.String()) {
+			v.report(start, SeverityError, "%q is not a valid lexical form for <%s>", sb.String(), datatype)
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// validLangTag checks the LANGTAG production of the N-Triples grammar.
+func validLangTag(tag string) bool {
+	parts := strings.Split(tag, "-")
+	for i, part := range parts {
+		if part == "" {
+			return false
+		}
+		for j := 0; j < len(part); j++ {
+			if !isAlnum(part[j]) || (i == 0 && '0' <= part[j] && part[j] <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// canonicalLangTag returns the case BCP47 recommends for a well-formed tag:
+// lowercase, with title case scripts and uppercase regions, up to the first
+// singleton.
+func canonicalLangTag(tag string) string {
+	parts := strings.Split(strings.ToLower(tag), "-")
+	for i := 1; i < len(parts); i++ {
+		part := parts[i]
+		if len(part) == 1 {
+			break
+		}
+		switch {
+		case len(part) == 4 && !isDigit(part[0]):
+			parts[i] = strings.ToUpper(part[:1]) + part[1:]
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// validLexical checks a lexical form, and for dates that the day exists.
+func validLexical(datatype string, re *regexp.Regexp, s string) bool {
+	if !re.MatchString(s) {
+		return false
+	}
+	if datatype != xsdNS+"date" {
+		return true
+	}
+	s = strings.TrimPrefix(s, "-")
+	i := strings.Index(s, "-")
+	year, _ := strconv.Atoi(s[:i])
+	month, _ := strconv.Atoi(s[i+1 : i+3])
+	day, _ := strconv.Atoi(s[i+4 : i+6])
+	days := []int{31, 28, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}[month-1]
+	if month == 2 && year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+		days = 29
+	}
+	return day <= days
+}
+
+// ValidationSummary counts lines and problems of a validation run.
+type ValidationSummary struct {
+	Lines    int `json:"lines"`
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+}
+
+// Validate checks every line read from r and calls fn for each problem
+// found, in line order. It stops early, if fn returns an error.
+func Validate(r io.Reader, fn func(Problem) error) (ValidationSummary, error) {
+	var summary ValidationSummary
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			summary.Lines++
+			for _, p := range ValidateNTriple(line) {
+				p.Line = summary.Lines
+				if p.Severity == SeverityError {
+					summary.Errors++
+				} else {
+					summary.Warnings++
+				}
+				if ferr := fn(p); ferr != nil {
+					return summary, ferr
+				}
+			}
+		}
+		if err == io.EOF {
+			return summary, nil
+		}
+		if err != nil {
+			return summary, err
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package ntto
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var ValidateNTripleTests = []struct {
+	in  string
+	out []Problem
+}{
+	{``, nil},
+	{`   # comment`, nil},
+	{`<http://a> <http://b> <http://c> .`, nil},
+	{`<http://a> <http://b> <http://c>. # comment`, nil},
+	{`_:b0 <http://b> _:b1.`, nil},
+	{`<http://a> <http://b> "x\"yä\n"@en-US .`, nil},
+	{`<http://a> <http://b> "12"^^<http://www.w3.org/2001/XMLSchema#integer> .`, nil},
+	{`<http://a> <http://b> "2020-02-29"^^<http://www.w3.org/2001/XMLSchema#date> .`, nil},
+	{`<http://a> <http://b> <http://c>`,
+		[]Problem{{Column: 33, Severity: SeverityError, Message: "expected '.' at end of triple"}}},
+	{`<a> <http://b> <http://c> .`,
+		[]Problem{{Column: 1, Severity: SeverityError, Message: "IRI <a> is not absolute"}}},
+	{`<http://a b> <http://b> <http://c> .`,
+		[]Problem{{Column: 10, Severity: SeverityError, Message: "character ' ' not allowed in IRI"}}},
+	{`<http://a/%zz> <http://b> <http://c> .`,
+		[]Problem{{Column: 1, Severity: SeverityError, Message: "IRI <http://a/%zz> has an invalid percent-encoding"}}},
+	{`"a" <http://b> <http://c> .`,
+		[]Problem{{Column: 1, Severity: SeverityError, Message: "expected subject IRI or blank node"}}},
+	{`<http://a> <http://b> "x\q" .`,
+		[]Problem{{Column: 25, Severity: SeverityError, Message: `invalid escape sequence \q`}}},
+	{`<http://a> <http://b> "x .`,
+		[]Problem{{Column: 23, Severity: SeverityError, Message: "unterminated literal"}}},
+	{`<http://a> <http://b> "x"@1de .`,
+		[]Problem{{Column: 26, Severity: SeverityError, Message: `invalid language tag "1de"`}}},
+	{`<http://a> <http://b> "x"@english-x .`,
+		[]Problem{{Column: 26, Severity: SeverityError, Message: `language tag "english-x" is not well-formed BCP47`}}},
+	{`<http://a> <http://b> "1.5"^^<http://www.w3.org/2001/XMLSchema#integer> .`,
+		[]Problem{{Column: 23, Severity: SeverityError, Message: `"1.5" is not a valid lexical form for <http://www.w3.org/2001/XMLSchema#integer>`}}},
+	{`<http://a> <http://b> "yes"^^<http://www.w3.org/2001/XMLSchema#boolean> .`,
+		[]Problem{{Column: 23, Severity: SeverityError, Message: `"yes" is not a valid lexical form for <http://www.w3.org/2001/XMLSchema#boolean>`}}},
+	{`<http://a> <http://b> "2019-02-29"^^<http://www.w3.org/2001/XMLSchema#date> .`,
+		[]Problem{{Column: 23, Severity: SeverityError, Message: `"2019-02-29" is not a valid lexical form for <http://www.w3.org/2001/XMLSchema#date>`}}},
+	{`<http://a> <http://b> "1,5"^^<http://www.w3.org/2001/XMLSchema#decimal> .`,
+		[]Problem{{Column: 23, Severity: SeverityError, Message: `"1,5" is not a valid lexical form for <http://www.w3.org/2001/XMLSchema#decimal>`}}},
+	{`<http://a> <http://b> "x"@EN-us .`,
+		[]Problem{{Column: 26, Severity: SeverityWarning, Message: `language tag "EN-us" is usually written "en-US"`}}},
+	{`<http://a> <http://b> "x"@sr-latn-rs-x-ab .`,
+		[]Problem{{Column: 26, Severity: SeverityWarning, Message: `language tag "sr-latn-rs-x-ab" is usually written "sr-Latn-RS-x-ab"`}}},
+	{`<http://a> <http://b> "12"^^<http://www.w3.org/2001/XMLSchema#integr> .`,
+		[]Problem{{Column: 29, Severity: SeverityWarning, Message: `unknown XML Schema datatype <http://www.w3.org/2001/XMLSchema#integr>`}}},
+	{`<http://a> <http://b> <http://c> . x`,
+		[]Problem{{Column: 36, Severity: SeverityError, Message: `unexpected "x" after end of triple`}}},
+	{`_:-a <http://b> <http://c> .`,
+		[]Problem{{Column: 1, Severity: SeverityError, Message: `invalid blank node label "_:-a"`}}},
+}
+
+func TestValidateNTriple(t *testing.T) {
+	for _, tt := range ValidateNTripleTests {
+		out := ValidateNTriple(tt.in)
+		if !reflect.DeepEqual(out, tt.out) {
+			t.Errorf("ValidateNTriple(%s) => %+v, want: %+v", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	in := strings.Join([]string{
+		`<http://a> <http://b> <http://c> .`,
+		`<a> <http://b> <http://c> .`,
+		`<http://a> <http://b> "x"@english-x .`,
+		`<http://a> <http://b> "x"@DE .`,
+		``,
+	}, "\n")
+	var lines []int
+	summary, err := Validate(strings.NewReader(in), func(p Problem) error {
+		lines = append(lines, p.Line)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ValidationSummary{Lines: 4, Errors: 2, Warnings: 1}); summary != want {
+		t.Errorf("Validate => %+v, want: %+v", summary, want)
+	}
+	if !reflect.DeepEqual(lines, []int{2, 3, 4}) {
+		t.Errorf("Validate => problems on lines %v, want: [2 3 4]", lines)
+	}
+}
@@ -0,0 +1,238 @@
+package ntto
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const voidNS = "http://rdfs.org/ns/void#"
+
+// VoID computes a VoID dataset description in a single pass. Distinct counts
+// are HyperLogLog estimates, partitions and vocabularies are kept in bounded
+// counters. VoID implements Encoder, so it can sit at the end of a pipeline.
+type VoID struct {
+	Triples      int64
+	Subjects     *HyperLogLog
+	Objects      *HyperLogLog
+	Predicates   *HyperLogLog
+	Classes      *HyperLogLog
+	Properties   *Counter
+	Entities     *Counter
+	Vocabularies *Counter
+	max          int
+}
+
+// NewVoID returns an empty description. Partitions and vocabularies keep at
+// most max keys, distinct counts use HyperLogLog with the given precision.
+func NewVoID(max int, precision uint8) *VoID {
+	return &VoID{
+		Subjects:     NewHyperLogLog(precision),
+		Objects:      NewHyperLogLog(precision),
+		Predicates:   NewHyperLogLog(precision),
+		Classes:      NewHyperLogLog(precision),
+		Properties:   NewCounter(max),
+		Entities:     NewCounter(max),
+		Vocabularies: NewCounter(max),
+		max:          max,
+	}
+}
+
+// Encode adds a triple to the description.
+func (v *VoID) Encode(t *Triple) error {
+	v.Triples++
+	v.Subjects.Add(t.Subject)
+	v.Objects.Add(t.formatObject())
+	v.Predicates.Add(t.Predicate)
+	v.Properties.Add(t.Predicate, 1)
+	v.Vocabularies.Add(Namespace(t.Predicate), 1)
+	if t.Predicate == rdfNS+"type" && t.ObjectKind == IRI {
+		v.Classes.Add(t.Object)
+		v.Entities.Add(t.Object, 1)
+		v.Vocabularies.Add(Namespace(t.Object), 1)
+	}
+	return nil
+}
+
+// Flush is a no-op.
+func (v *VoID) Flush() error {
+	return nil
+}
+
+// distinct returns the exact number of keys, if the counter never had to
+// drop one, and the estimate otherwise.
+func (v *VoID) distinct(c *Counter, h *HyperLogLog) int64 {
+	if c.Len() < v.max {
+		return int64(c.Len())
+	}
+	return int64(h.Count())
+}
+
+func integerTerm(s, p string, n int64) *Triple {
+	return &Triple{Subject: s, Predicate: p, Object: strconv.FormatInt(n, 10),
+		ObjectKind: Literal, Datatype: xsdNS + "integer"}
+}
+
+// Description returns the VoID triples describing the dataset IRI.
+// Partitions are blank nodes, ordered by size.
+func (v *VoID) Description(dataset string) []*Triple {
+	triples := []*Triple{
+		{Subject: dataset, Predicate: rdfNS + "type", Object: voidNS + "Dataset"},
+		integerTerm(dataset, voidNS+"triples", v.Triples),
+		integerTerm(dataset, voidNS+"distinctSubjects", int64(v.Subjects.Count())),
+		integerTerm(dataset, voidNS+"distinctObjects", int64(v.Objects.Count())),
+		integerTerm(dataset, voidNS+"properties", v.distinct(v.Properties, v.Predicates)),
+		integerTerm(dataset, voidNS+"classes", v.distinct(v.Entities, v.Classes)),
+	}
+	for _, c := range v.Vocabularies.Top(0) {
+		if c.Key == "_:" {
+			continue
+		}
+		triples = append(triples, &Triple{Subject: dataset, Predicate: voidNS + "vocabulary", Object: c.Key})
+	}
+	for i, c := range v.Properties.Top(0) {
+		node := fmt.Sprintf("_:property%d", i+1)
+		triples = append(triples,
+			&Triple{Subject: dataset, Predicate: voidNS + "propertyPartition", Object: node, ObjectKind: BlankNode},
+			&Triple{Subject: node, Predicate: voidNS + "property", Object: c.Key},
+			integerTerm(node, voidNS+"triples", c.Count))
+	}
+	for i, c := range v.Entities.Top(0) {
+		node := fmt.Sprintf("_:class%d", i+1)
+		triples = append(triples,
+			&Triple{Subject: dataset, Predicate: voidNS + "classPartition", Object: node, ObjectKind: BlankNode},
+			&Triple{Subject: node, Predicate: voidNS + "class", Object: c.Key},
+			integerTerm(node, voidNS+"entities", c.Count))
+	}
+	return triples
+}
+
+// turtleLocal matches local names that need no escaping in a prefixed name.
+var turtleLocal = regexp.MustCompile(`^[A-Za-z0-9_]([A-Za-z0-9_.-]*[A-Za-z0-9_-])?$`)
+
+// WriteTurtle writes triples as Turtle, using prefixed names for the rules
+// that match. Blank nodes that are the object of exactly one triple are
+// nested as property lists. Triples are grouped by subject in order of first
+// appearance.
+func WriteTurtle(w io.Writer, triples []*Triple, rules []Rule) error {
+	index := newPrefixIndex(curieRules(rules))
+	var used []Rule
+	seen := make(map[string]bool)
+	name := func(iri string) string {
+		if rule, ok := index.match(iri); ok && turtleLocal.MatchString(iri[len(rule.Prefix):]) {
+			if !seen[rule.Prefix] {
+				seen[rule.Prefix] = true
+				used = append(used, rule)
+			}
+			return rule.Shortcut + ":" + iri[len(rule.Prefix):]
+		}
+		return "<" + iri + ">"
+	}
+
+	var subjects []string
+	bySubject := make(map[string][]*Triple)
+	refs := make(map[string]int)
+	parent := make(map[string]string)
+	for _, t := range triples {
+		if _, ok := bySubject[t.Subject]; !ok {
+			subjects = append(subjects, t.Subject)
+		}
+		bySubject[t.Subject] = append(bySubject[t.Subject], t)
+		if t.ObjectKind == BlankNode {
+			refs[t.Object]++
+			parent[t.Object] = t.Subject
+		}
+	}
+	nested := func(node string) bool {
+		if !strings.HasPrefix(node, "_:") || refs[node] != 1 || len(bySubject[node]) == 0 {
+			return false
+		}
+		// blank nodes on a cycle must keep their label
+		for p, n := parent[node], 0; n < len(parent); p, n = parent[p], n+1 {
+			if p == node {
+				return false
+			}
+			if refs[p] != 1 {
+				break
+			}
+		}
+		return true
+	}
+
+	var object func(t *Triple) string
+	verb := func(t *Triple) string {
+		if t.Predicate == rdfNS+"type" {
+			return "a"
+		}
+		return name(t.Predicate)
+	}
+	object = func(t *Triple) string {
+		switch t.ObjectKind {
+		case BlankNode:
+			if !nested(t.Object) {
+				return t.Object
+			}
+			var parts []string
+			for _, u := range bySubject[t.Object] {
+				parts = append(parts, verb(u)+" "+object(u))
+			}
+			return "[ " + strings.Join(parts, " ; ") + " ]"
+		case Literal:
+			switch {
+			case t.Datatype == xsdNS+"integer":
+				return t.Object
+			case t.Lang != "":
+				return `"` + t.Object + `"@` + t.Lang
+			case t.Datatype != "":
+				return `"` + t.Object + `"^^` + name(t.Datatype)
+			}
+			return `"` + t.Object + `"`
+		}
+		return name(t.Object)
+	}
+
+	var body strings.Builder
+	first := true
+	for _, s := range subjects {
+		if nested(s) {
+			continue
+		}
+		if !first {
+			body.WriteString("\n")
+		}
+		first = false
+		if strings.HasPrefix(s, "_:") {
+			body.WriteString(s + "\n")
+		} else {
+			body.WriteString(name(s) + "\n")
+		}
+		ts := bySubject[s]
+		for i, t := range ts {
+			switch {
+			case i == 0:
+				body.WriteString("    " + verb(t) + " ")
+			case ts[i-1].Predicate == t.Predicate:
+				body.WriteString(" ,\n        ")
+			default:
+				body.WriteString(" ;\n    " + verb(t) + " ")
+			}
+			body.WriteString(object(t))
+		}
+		body.WriteString(" .\n")
+	}
+
+	for _, rule := range used {
+		if _, err := fmt.Fprintf(w, "@prefix %s: <%s> .\n", rule.Shortcut, rule.Prefix); err != nil {
+			return err
+		}
+	}
+	if len(used) > 0 {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, body.String())
+	return err
+}
@@ -0,0 +1,91 @@
+package ntto
+
+import (
+	"bytes"
+	"testing"
+)
+
+var voidInput = []string{
+	`<http://ex/a> <http://www.w3.org/1999/02/22-rdf-syntax-ns#type> <http://xmlns.com/foaf/0.1/Person> .`,
+	`<http://ex/a> <http://xmlns.com/foaf/0.1/name> "A"@en .`,
+	`<http://ex/b> <http://xmlns.com/foaf/0.1/name> "B" .`,
+	`<http://ex/b> <http://xmlns.com/foaf/0.1/knows> <http://ex/a> .`,
+}
+
+func newTestVoID(t *testing.T) *VoID {
+	v := NewVoID(100, 14)
+	for _, line := range voidInput {
+		triple, err := ParseNTriple(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.Encode(triple); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return v
+}
+
+func TestVoIDTurtle(t *testing.T) {
+	rules, err := ParseRules(DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteTurtle(&buf, newTestVoID(t).Description("http://ex/dataset"), rules); err != nil {
+		t.Fatal(err)
+	}
+	want := `@prefix void: <http://rdfs.org/ns/void#> .
+@prefix foaf: <http://xmlns.com/foaf/0.1/> .
+@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .
+
+<http://ex/dataset>
+    a void:Dataset ;
+    void:triples 4 ;
+    void:distinctSubjects 2 ;
+    void:distinctObjects 4 ;
+    void:properties 3 ;
+    void:classes 1 ;
+    void:vocabulary <http://xmlns.com/foaf/0.1/> ,
+        <http://www.w3.org/1999/02/22-rdf-syntax-ns#> ;
+    void:propertyPartition [ void:property foaf:name ; void:triples 2 ] ,
+        [ void:property rdf:type ; void:triples 1 ] ,
+        [ void:property foaf:knows ; void:triples 1 ] ;
+    void:classPartition [ void:class foaf:Person ; void:entities 1 ] .
+`
+	if buf.String() != want {
+		t.Errorf("WriteTurtle => %s, want: %s", buf.String(), want)
+	}
+}
+
+func TestVoIDDescription(t *testing.T) {
+	triples := newTestVoID(t).Description("http://ex/dataset")
+	// type, five counts, two vocabularies, three property and one class partition
+	if len(triples) != 1+5+2+3*3+1*3 {
+		t.Errorf("Description => %d triples", len(triples))
+	}
+	for _, triple := range triples {
+		parsed, err := ParseNTriple(triple.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *parsed != *triple {
+			t.Errorf("Description => %s does not round trip", triple)
+		}
+	}
+}
+
+func TestWriteTurtleCycle(t *testing.T) {
+	triples := []*Triple{
+		{Subject: "_:a", Predicate: "http://ex/p", Object: "_:b", ObjectKind: BlankNode},
+		{Subject: "_:b", Predicate: "http://ex/p", Object: "_:a", ObjectKind: BlankNode},
+	}
+	var buf bytes.Buffer
+	if err := WriteTurtle(&buf, triples, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "_:a\n    <http://ex/p> _:b .\n\n_:b\n    <http://ex/p> _:a .\n"
+	if buf.String() != want {
+		t.Errorf("WriteTurtle => %q, want: %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,183 @@
+package ntto
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	wikidataEntity    = "http://www.wikidata.org/entity/"
+	wikidataStatement = "http://www.wikidata.org/entity/statement/"
+	wikidataValue     = "http://www.wikidata.org/value/"
+	wikidataReference = "http://www.wikidata.org/reference/"
+	wikidataProp      = "http://www.wikidata.org/prop/"
+	wikidataDirect    = "http://www.wikidata.org/prop/direct/"
+	wikidataDirectN   = "http://www.wikidata.org/prop/direct-normalized/"
+
+	rdfsLabel         = "http://www.w3.org/2000/01/rdf-schema#label"
+	skosPrefLabel     = "http://www.w3.org/2004/02/skos/core#prefLabel"
+	skosAltLabel      = "http://www.w3.org/2004/02/skos/core#altLabel"
+	schemaName        = "http://schema.org/name"
+	schemaDescription = "http://schema.org/description"
+)
+
+// WikidataFilter selects triples of a Wikidata dump. IRIs may be abbreviated
+// with the rules given, as after ntto abbreviate.
+type WikidataFilter struct {
+	// Truthy keeps only the simple statements of the truthy dump: triples
+	// with wdt: predicates are kept, statement, qualifier and reference
+	// predicates (p:, ps:, pq:, pr: and their value forms) are dropped, as
+	// are all triples about statement, value and reference nodes. Labels,
+	// descriptions and other triples about entities stay.
+	Truthy bool
+	// Languages restricts labels, descriptions and aliases to these
+	// language tags. Other literals are not affected. Empty means all.
+	Languages []string
+	index     *prefixIndex
+}
+
+// NewWikidataFilter returns a filter, which keeps everything until Truthy or
+// Languages are set.
+func NewWikidataFilter(rules []Rule) *WikidataFilter {
+	return &WikidataFilter{index: newPrefixIndex(rules)}
+}
+
+// Keep reports whether a triple passes the filter.
+func (f *WikidataFilter) Keep(t *Triple) bool {
+	if f.Truthy {
+		subject := f.index.expand(t.Subject)
+		if strings.HasPrefix(subject, wikidataStatement) ||
+			strings.HasPrefix(subject, wikidataValue) ||
+			strings.HasPrefix(subject, wikidataReference) {
+			return false
+		}
+		predicate := f.index.expand(t.Predicate)
+		if strings.HasPrefix(predicate, wikidataProp) &&
+			!strings.HasPrefix(predicate, wikidataDirect) &&
+			!strings.HasPrefix(predicate, wikidataDirectN) {
+			return false
+		}
+	}
+	if len(f.Languages) > 0 && t.ObjectKind == Literal && wikidataTermKey(f.index.expand(t.Predicate)) != "" {
+		return matchLanguage(t.Lang, f.Languages)
+	}
+	return true
+}
+
+// matchLanguage reports whether a language tag is in the list, ignoring case.
+func matchLanguage(lang string, languages []string) bool {
+	for _, l := range languages {
+		if strings.EqualFold(lang, l) {
+			return true
+		}
+	}
+	return false
+}
+
+// wikidataTermKey returns the key of a term predicate in WikidataTerms, or
+// an empty string for other predicates.
+func wikidataTermKey(predicate string) string {
+	switch predicate {
+	case rdfsLabel, skosPrefLabel, schemaName:
+		return "labels"
+	case schemaDescription:
+		return "descriptions"
+	case skosAltLabel:
+		return "aliases"
+	}
+	return ""
+}
+
+// WikidataTerms are the labels, descriptions and aliases of an entity, keyed
+// by language, like in the Wikidata JSON dumps.
+type WikidataTerms struct {
+	ID           string              `json:"id"`
+	Labels       map[string]string   `json:"labels,omitempty"`
+	Descriptions map[string]string   `json:"descriptions,omitempty"`
+	Aliases      map[string][]string `json:"aliases,omitempty"`
+}
+
+// WikidataTermEncoder writes one WikidataTerms document per entity and line.
+// Triples of an entity must be consecutive, as in the dumps. Entities
+// without terms and non-term triples are skipped. Labels appear three times
+// in full dumps, as rdfs:label, skos:prefLabel and schema:name; the first
+// one per language is used.
+type WikidataTermEncoder struct {
+	w         io.Writer
+	index     *prefixIndex
+	languages []string
+	terms     *WikidataTerms
+}
+
+// NewWikidataTermEncoder returns an encoder for term documents. Abbreviated
+// predicates are recognized with rules. If languages are given, other
+// languages are left out.
+func NewWikidataTermEncoder(w io.Writer, rules []Rule, languages []string) *WikidataTermEncoder {
+	return &WikidataTermEncoder{w: w, index: newPrefixIndex(rules), languages: languages}
+}
+
+// Encode adds the term of a triple to the document of its entity.
+func (e *WikidataTermEncoder) Encode(t *Triple) error {
+	if t.ObjectKind != Literal || t.Lang == "" {
+		return nil
+	}
+	key := wikidataTermKey(e.index.expand(t.Predicate))
+	if key == "" {
+		return nil
+	}
+	if len(e.languages) > 0 && !matchLanguage(t.Lang, e.languages) {
+		return nil
+	}
+	id := strings.TrimPrefix(e.index.expand(t.Subject), wikidataEntity)
+	if e.terms != nil && e.terms.ID != id {
+		if err := e.writeDocument(); err != nil {
+			return err
+		}
+	}
+	if e.terms == nil {
+		e.terms = &WikidataTerms{ID: id}
+	}
+	value := unescapeLiteral(t.Object)
+	switch key {
+	case "labels":
+		if e.terms.Labels == nil {
+			e.terms.Labels = make(map[string]string)
+		}
+		if _, ok := e.terms.Labels[t.Lang]; !ok {
+			e.terms.Labels[t.Lang] = value
+		}
+	case "descriptions":
+		if e.terms.Descriptions == nil {
+			e.terms.Descriptions = make(map[string]string)
+		}
+		e.terms.Descriptions[t.Lang] = value
+	case "aliases":
+		if e.terms.Aliases == nil {
+			e.terms.Aliases = make(map[string][]string)
+		}
+		e.terms.Aliases[t.Lang] = append(e.terms.Aliases[t.Lang], value)
+	}
+	return nil
+}
+
+func (e *WikidataTermEncoder) writeDocument() error {
+	b, err := json.Marshal(e.terms)
+	if err != nil {
+		return err
+	}
+	e.terms = nil
+	if _, err := e.w.Write(b); err != nil {
+		return err
+	}
+	_, err = e.w.Write([]byte("\n"))
+	return err
+}
+
+// Flush writes the last document.
+func (e *WikidataTermEncoder) Flush() error {
+	if e.terms == nil {
+		return nil
+	}
+	return e.writeDocument()
+}
@@ -0,0 +1,104 @@
+package ntto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const wikidataDump = `<http://www.wikidata.org/entity/Q42> <http://www.wikidata.org/prop/direct/P31> <http://www.wikidata.org/entity/Q5> .
+<http://www.wikidata.org/entity/Q42> <http://www.wikidata.org/prop/P31> <http://www.wikidata.org/entity/statement/Q42-1> .
+<http://www.wikidata.org/entity/statement/Q42-1> <http://www.wikidata.org/prop/statement/P31> <http://www.wikidata.org/entity/Q5> .
+<http://www.wikidata.org/entity/statement/Q42-1> <http://wikiba.se/ontology#rank> <http://wikiba.se/ontology#NormalRank> .
+<http://www.wikidata.org/reference/abc> <http://www.wikidata.org/prop/reference/P143> <http://www.wikidata.org/entity/Q328> .
+<http://www.wikidata.org/entity/Q42> <http://www.w3.org/2000/01/rdf-schema#label> "Douglas Adams"@en .
+<http://www.wikidata.org/entity/Q42> <http://www.w3.org/2004/02/skos/core#prefLabel> "Douglas Adams"@en .
+<http://www.wikidata.org/entity/Q42> <http://www.w3.org/2000/01/rdf-schema#label> "Douglas Adams"@de .
+<http://www.wikidata.org/entity/Q42> <http://schema.org/description> "English writer"@en .
+<http://www.wikidata.org/entity/Q42> <http://schema.org/description> "britischer Schriftsteller"@de .
+<http://www.wikidata.org/entity/Q42> <http://www.w3.org/2004/02/skos/core#altLabel> "Douglas Noel Adams"@en .
+<http://www.wikidata.org/entity/Q42> <http://www.w3.org/2004/02/skos/core#altLabel> "DNA"@en .
+<http://www.wikidata.org/entity/Q5> <http://www.w3.org/2000/01/rdf-schema#label> "human"@en .
+`
+
+func parseDump(t *testing.T, s string) []*Triple {
+	var triples []*Triple
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		triple, err := ParseNTriple(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		triples = append(triples, triple)
+	}
+	return triples
+}
+
+func TestWikidataFilter(t *testing.T) {
+	rules, _ := BuiltinProfile("wikidata")
+	triples := parseDump(t, wikidataDump)
+
+	f := NewWikidataFilter(rules)
+	f.Truthy = true
+	f.Languages = []string{"de"}
+	var kept []string
+	for _, triple := range triples {
+		if f.Keep(triple) {
+			kept = append(kept, triple.String())
+		}
+	}
+	want := []string{
+		"<http://www.wikidata.org/entity/Q42> <http://www.wikidata.org/prop/direct/P31> <http://www.wikidata.org/entity/Q5> .",
+		`<http://www.wikidata.org/entity/Q42> <http://www.w3.org/2000/01/rdf-schema#label> "Douglas Adams"@de .`,
+		`<http://www.wikidata.org/entity/Q42> <http://schema.org/description> "britischer Schriftsteller"@de .`,
+	}
+	if strings.Join(kept, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", kept, want)
+	}
+
+	// abbreviated input
+	abbreviator := NewAbbreviator(rules, "<NULL>")
+	count := 0
+	for _, triple := range triples {
+		abbreviated, err := ParseNTriple(abbreviator.Abbreviate(triple.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f.Keep(abbreviated) {
+			count++
+		}
+	}
+	if count != len(want) {
+		t.Errorf("got %d abbreviated triples, want %d", count, len(want))
+	}
+}
+
+func TestWikidataTermEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewWikidataTermEncoder(&buf, nil, nil)
+	for _, triple := range parseDump(t, wikidataDump) {
+		if err := enc.Encode(triple); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"Q42","labels":{"de":"Douglas Adams","en":"Douglas Adams"},"descriptions":{"de":"britischer Schriftsteller","en":"English writer"},"aliases":{"en":["Douglas Noel Adams","DNA"]}}
+{"id":"Q5","labels":{"en":"human"}}
+`
+	if buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+
+	buf.Reset()
+	enc = NewWikidataTermEncoder(&buf, nil, []string{"DE"})
+	for _, triple := range parseDump(t, wikidataDump) {
+		enc.Encode(triple)
+	}
+	enc.Flush()
+	want = `{"id":"Q42","labels":{"de":"Douglas Adams"},"descriptions":{"de":"britischer Schriftsteller"}}
+`
+	if buf.String() != want {
+		t.Errorf("got %s, want %s", buf.String(), want)
+	}
+}